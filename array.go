@@ -0,0 +1,210 @@
+package mongopagination
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"strings"
+)
+
+// FindArray pages elements of array at path in the first document matching
+// filter of query and decodes them into Decoder. Sort fields and
+// elementFilter refer to fields of array elements, when either is set the
+// array is unwound to sort and filter elements, otherwise the page is cut
+// with $slice and total is $size of the array
+func (paging *pagingQuery) FindArray(path string, elementFilter interface{}) (*PaginatedData, error) {
+	err := paging.validate(AggregateOperation, func() error {
+		if err := paging.validateQuery(true); err != nil {
+			return err
+		}
+		if paging.FilterQuery == nil {
+			return errors.New(NilFilterError)
+		}
+		if path == "" {
+			return errors.New(ArrayPathError)
+		}
+		return paging.unranked()
+	})
+	if err != nil {
+		return nil, err
+	}
+	pipeline := paging.arrayPipeline(path, elementFilter, paging.getSkip())
+	return paging.paginate(AggregateOperation, pipeline, func(ctx context.Context) (*PaginatedData, error) {
+		return paging.findArray(ctx, path, elementFilter)
+	})
+}
+
+// findArray queries page of array elements and decodes them into Decoder
+func (paging *pagingQuery) findArray(ctx context.Context, path string, elementFilter interface{}) (*PaginatedData, error) {
+	skip := paging.getSkip()
+	items, count, err := paging.arrayPage(ctx, paging.arrayPipeline(path, elementFilter, skip))
+	if err != nil {
+		return nil, err
+	}
+	paginationInfo, err := clampPage(paging.paginator(count), paging.OutOfRange)
+	if err != nil {
+		return nil, err
+	}
+	if paginationInfo.Offset != skip {
+		// page was clamped so last page has to be queried again
+		items, count, err = paging.arrayPage(ctx, paging.arrayPipeline(path, elementFilter, paginationInfo.Offset))
+		if err != nil {
+			return nil, err
+		}
+		paginationInfo = newPaginator(count, paginationInfo.Page, paginationInfo.Limit)
+	}
+	if err := decodeValues(items, paging.Decoder); err != nil {
+		return nil, err
+	}
+	paginationInfo.Count = int64(len(items))
+	result := PaginatedData{
+		Pagination: *paginationInfo.PaginationData(),
+	}
+	return &result, nil
+}
+
+// arrayPipeline returns pipeline projecting page of array elements at path
+// starting at skip as items along with total number of elements
+func (paging *pagingQuery) arrayPipeline(path string, elementFilter interface{}, skip int64) []bson.M {
+	field := "$" + path
+	pipeline := []bson.M{
+		{"$match": paging.FilterQuery},
+		{"$limit": 1},
+	}
+	if elementFilter == nil && len(paging.SortFields) == 0 {
+		array := bson.M{"$ifNull": bson.A{field, bson.A{}}}
+		return append(pipeline, bson.M{"$project": bson.M{
+			"_id":   0,
+			"total": bson.M{"$size": array},
+			"items": bson.M{"$slice": bson.A{array, skip, paging.LimitCount}},
+		}})
+	}
+
+	pipeline = append(pipeline, bson.M{"$unwind": field})
+	if elementFilter != nil {
+		pipeline = append(pipeline, bson.M{"$match": prefixFields(elementFilter, path)})
+	}
+	var items []bson.M
+	if len(paging.SortFields) > 0 {
+		sort := make(bson.D, len(paging.SortFields))
+		for i, e := range paging.SortFields {
+			sort[i] = bson.E{Key: path + "." + e.Key, Value: e.Value}
+		}
+		items = append(items, bson.M{"$sort": sort})
+	}
+	items = append(items, bson.M{"$skip": skip}, bson.M{"$limit": paging.LimitCount})
+	return append(pipeline,
+		bson.M{"$facet": bson.M{
+			"items": items,
+			"total": []bson.M{{"$count": "count"}},
+		}},
+		bson.M{"$project": bson.M{
+			"items": "$items." + path,
+			"total": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$total.count", 0}}, 0}},
+		}},
+	)
+}
+
+// arrayPage runs array pipeline and returns its items and total
+func (paging *pagingQuery) arrayPage(ctx context.Context, pipeline []bson.M) ([]bson.RawValue, int64, error) {
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, 0, err
+	}
+	opt := options.Aggregate()
+	if paging.Collation != nil {
+		opt.SetCollation(paging.Collation)
+	}
+	op := Operation{
+		Kind:             AggregateOperation,
+		Ctx:              ctx,
+		Collection:       collection,
+		Pipeline:         pipeline,
+		AggregateOptions: opt,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, 0, err
+	}
+	cursor := op.Cursor
+	defer cursor.Close(ctx)
+	var page struct {
+		Items []bson.RawValue `bson:"items"`
+		Total int64           `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&page); err != nil {
+			return nil, 0, err
+		}
+	}
+	return page.Items, page.Total, cursor.Err()
+}
+
+// prefixFields returns copy of filter on array elements with field names
+// prefixed with path of the array, operators are kept as they are
+func prefixFields(filter interface{}, path string) interface{} {
+	switch v := filter.(type) {
+	case bson.M:
+		return prefixFields(map[string]interface{}(v), path)
+	case map[string]interface{}:
+		prefixed := make(bson.M, len(v))
+		for key, value := range v {
+			if strings.HasPrefix(key, "$") {
+				prefixed[key] = prefixOperands(value, path)
+			} else {
+				prefixed[path+"."+key] = value
+			}
+		}
+		return prefixed
+	case bson.D:
+		prefixed := make(bson.D, len(v))
+		for i, e := range v {
+			if strings.HasPrefix(e.Key, "$") {
+				prefixed[i] = bson.E{Key: e.Key, Value: prefixOperands(e.Value, path)}
+			} else {
+				prefixed[i] = bson.E{Key: path + "." + e.Key, Value: e.Value}
+			}
+		}
+		return prefixed
+	}
+	return filter
+}
+
+// prefixOperands prefixes field names of filters of logical operator
+func prefixOperands(operands interface{}, path string) interface{} {
+	switch v := operands.(type) {
+	case []bson.M:
+		prefixed := make(bson.A, len(v))
+		for i, operand := range v {
+			prefixed[i] = prefixFields(operand, path)
+		}
+		return prefixed
+	case bson.A:
+		return prefixOperands([]interface{}(v), path)
+	case []interface{}:
+		prefixed := make(bson.A, len(v))
+		for i, operand := range v {
+			prefixed[i] = prefixFields(operand, path)
+		}
+		return prefixed
+	}
+	// operands of other operators such as $expr are not field names
+	return operands
+}
+
+// decodeValues decodes values into pointer to slice
+func decodeValues(values []bson.RawValue, docs interface{}) error {
+	v := reflect.ValueOf(docs)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New(DecodeSliceError)
+	}
+	slice := reflect.MakeSlice(v.Elem().Type(), len(values), len(values))
+	for i, value := range values {
+		if err := value.Unmarshal(slice.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	v.Elem().Set(slice)
+	return nil
+}
@@ -0,0 +1,151 @@
+package mongopagination
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArrayPipeline(t *testing.T) {
+	filter := bson.M{"_id": "post-1"}
+	paging := New(nil).Limit(10).Page(2).Filter(filter).(*pagingQuery)
+	array := bson.M{"$ifNull": bson.A{"$comments", bson.A{}}}
+	expected := []bson.M{
+		{"$match": filter},
+		{"$limit": 1},
+		{"$project": bson.M{
+			"_id":   0,
+			"total": bson.M{"$size": array},
+			"items": bson.M{"$slice": bson.A{array, int64(10), int64(10)}},
+		}},
+	}
+	if pipeline := paging.arrayPipeline("comments", nil, 10); !reflect.DeepEqual(pipeline, expected) {
+		t.Errorf("expected $slice pipeline %v, got %v", expected, pipeline)
+	}
+
+	paging.Sort("createdAt", -1)
+	pipeline := paging.arrayPipeline("comments", bson.M{"approved": true}, 10)
+	if len(pipeline) != 6 {
+		t.Fatalf("expected $unwind pipeline, got %v", pipeline)
+	}
+	if !reflect.DeepEqual(pipeline[2], bson.M{"$unwind": "$comments"}) ||
+		!reflect.DeepEqual(pipeline[3], bson.M{"$match": bson.M{"comments.approved": true}}) {
+		t.Errorf("expected elements to be unwound and filtered, got %v", pipeline)
+	}
+	items := pipeline[4]["$facet"].(bson.M)["items"].([]bson.M)
+	if !reflect.DeepEqual(items[0], bson.M{"$sort": bson.D{{Key: "comments.createdAt", Value: -1}}}) {
+		t.Errorf("expected elements sorted on element fields, got %v", items)
+	}
+}
+
+func TestPrefixFields(t *testing.T) {
+	filter := bson.M{
+		"approved": true,
+		"$or":      []bson.M{{"author": "ann"}, {"likes": bson.M{"$gt": 5}}},
+		"$expr":    bson.M{"$gt": bson.A{"$likes", 1}},
+	}
+	expected := bson.M{
+		"comments.approved": true,
+		"$or":               bson.A{bson.M{"comments.author": "ann"}, bson.M{"comments.likes": bson.M{"$gt": 5}}},
+		"$expr":             bson.M{"$gt": bson.A{"$likes", 1}},
+	}
+	if prefixed := prefixFields(filter, "comments"); !reflect.DeepEqual(prefixed, expected) {
+		t.Errorf("expected %v, got %v", expected, prefixed)
+	}
+}
+
+func TestDecodeValues(t *testing.T) {
+	values := []bson.RawValue{
+		{Type: bsontype.String, Value: bsoncore.AppendString(nil, "go")},
+		{Type: bsontype.String, Value: bsoncore.AppendString(nil, "mongo")},
+	}
+	var tags []string
+	if err := decodeValues(values, &tags); err != nil || !reflect.DeepEqual(tags, []string{"go", "mongo"}) {
+		t.Errorf("expected decoded tags, got %v, %v", tags, err)
+	}
+	if err := decodeValues(values, tags); err == nil || err.Error() != DecodeSliceError {
+		t.Errorf("expected decode slice error, got %v", err)
+	}
+}
+
+func TestPagingQuery_FindArray(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("ArrayTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	type comment struct {
+		Author   string `bson:"author"`
+		Likes    int    `bson:"likes"`
+		Approved bool   `bson:"approved"`
+	}
+	var comments bson.A
+	for i := 1; i <= 7; i++ {
+		comments = append(comments, comment{Author: fmt.Sprintf("c-%d", i), Likes: i, Approved: i%2 == 1})
+	}
+	docs := []interface{}{
+		bson.M{"_id": "post-1", "comments": comments},
+		bson.M{"_id": "post-2", "comments": bson.A{comment{Author: "other", Likes: 9, Approved: true}}},
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	authors := func(comments []comment) []string {
+		var authors []string
+		for _, c := range comments {
+			authors = append(authors, c.Author)
+		}
+		return authors
+	}
+
+	// elements are cut with $slice without element filter and sort
+	var page []comment
+	paginatedData, err := New(collection).Context(ctx).Limit(3).Page(3).Filter(bson.M{"_id": "post-1"}).Decode(&page).
+		FindArray("comments", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 7 || paginatedData.Pagination.TotalPage != 3 {
+		t.Errorf("expected total of array elements, got %+v", paginatedData.Pagination)
+	}
+	if got := authors(page); !reflect.DeepEqual(got, []string{"c-7"}) {
+		t.Errorf("expected last element on last page, got %v", got)
+	}
+
+	// elements are unwound to be filtered and sorted
+	for p, expected := range map[int64][]string{1: {"c-7", "c-5"}, 2: {"c-3", "c-1"}} {
+		page = nil
+		paginatedData, err = New(collection).Context(ctx).Limit(2).Page(p).Sort("likes", -1).Filter(bson.M{"_id": "post-1"}).Decode(&page).
+			FindArray("comments", bson.M{"approved": true})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if paginatedData.Pagination.Total != 4 || paginatedData.Pagination.TotalPage != 2 {
+			t.Errorf("expected total of approved elements, got %+v", paginatedData.Pagination)
+		}
+		if got := authors(page); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected page %d to hold %v, got %v", p, expected, got)
+		}
+	}
+
+	// array of no matching document is empty
+	paginatedData, err = New(collection).Context(ctx).Limit(2).Page(1).Filter(bson.M{"_id": "post-3"}).Decode(&page).
+		FindArray("comments", nil)
+	if err != nil || paginatedData.Pagination.Total != 0 || len(page) != 0 {
+		t.Errorf("expected empty page, got %v, %v, %v", paginatedData, page, err)
+	}
+}
+
+func TestPagingQuery_FindArrayPath(t *testing.T) {
+	var comments []TodoTest
+	_, err := New(nil).Limit(5).Page(1).Filter(bson.M{}).Decode(&comments).FindArray("", nil)
+	if err == nil || err.Error() != ArrayPathError {
+		t.Errorf("expected array path error, got %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package mongopagination
+
+import (
+	"container/list"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheEntryKind tells whether cache entry holds total or page data
+type CacheEntryKind int
+
+// Cache entry kinds
+const (
+	CacheTotal CacheEntryKind = iota
+	CachePage
+)
+
+// CacheKey identifies cached total or page of paginated query. Query is
+// canonical extended JSON of filter or pipeline, as changed by interceptors,
+// along with collation, page entries of Find also include sort, projection,
+// skip and limit
+type CacheKey struct {
+	Kind       CacheEntryKind
+	Collection string
+	Query      string
+}
+
+// Cache stores totals and pages of paginated queries. Totals are stored as
+// int64 and pages as []bson.Raw
+type Cache interface {
+	Get(key CacheKey) (value interface{}, ok bool)
+	Set(key CacheKey, value interface{})
+	// Invalidate removes all entries of collection namespace
+	Invalidate(collection string)
+}
+
+// CacheNamespace returns namespace of collection used in cache keys
+func CacheNamespace(collection *mongo.Collection) string {
+	if collection == nil {
+		return ""
+	}
+	return collection.Database().Name() + "." + collection.Name()
+}
+
+// SetCache is function to set cache serving totals and pages of query.
+// Cache is looked up once interceptors have run, so queries scoped by
+// interceptors such as tenant filters do not share entries
+func (paging *pagingQuery) SetCache(cache Cache) PagingQuery {
+	paging.ResultCache = cache
+	return paging
+}
+
+// operationKey returns key of cache entry holding result of count, find or
+// aggregate operation. It is built once interceptors have run so that filter
+// or pipeline changed by them is part of the key
+func operationKey(op *Operation) (CacheKey, bool) {
+	kind := CachePage
+	var parts bson.D
+	switch op.Kind {
+	case CountOperation:
+		kind = CacheTotal
+		parts = bson.D{{Key: "query", Value: canonicalQuery(op.Filter)}}
+		if opt := op.CountOptions; opt != nil {
+			parts = append(parts,
+				bson.E{Key: "collation", Value: opt.Collation},
+				bson.E{Key: "skip", Value: opt.Skip},
+				bson.E{Key: "limit", Value: opt.Limit},
+			)
+		}
+	case FindOperation:
+		parts = bson.D{{Key: "query", Value: canonicalQuery(op.Filter)}}
+		if opt := op.FindOptions; opt != nil {
+			parts = append(parts,
+				bson.E{Key: "collation", Value: opt.Collation},
+				bson.E{Key: "sort", Value: canonicalQuery(opt.Sort)},
+				bson.E{Key: "projection", Value: canonicalQuery(opt.Projection)},
+				bson.E{Key: "skip", Value: opt.Skip},
+				bson.E{Key: "limit", Value: opt.Limit},
+			)
+		}
+	case AggregateOperation:
+		parts = bson.D{{Key: "query", Value: canonicalQuery(op.Pipeline)}}
+		if opt := op.AggregateOptions; opt != nil {
+			parts = append(parts, bson.E{Key: "collation", Value: opt.Collation})
+		}
+	default:
+		return CacheKey{}, false
+	}
+	canonical, err := bson.MarshalExtJSON(parts, true, false)
+	if err != nil {
+		// queries which cannot be represented are not cached
+		return CacheKey{}, false
+	}
+	return CacheKey{Kind: kind, Collection: CacheNamespace(op.Collection), Query: string(canonical)}, true
+}
+
+// canonicalQuery returns copy of query with map keys sorted
+// so that equal queries produce equal cache keys
+func canonicalQuery(query interface{}) interface{} {
+	switch v := query.(type) {
+	case bson.M:
+		return canonicalQuery(map[string]interface{}(v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		canonical := make(bson.D, 0, len(v))
+		for _, key := range keys {
+			canonical = append(canonical, bson.E{Key: key, Value: canonicalQuery(v[key])})
+		}
+		return canonical
+	case bson.D:
+		canonical := make(bson.D, 0, len(v))
+		for _, e := range v {
+			canonical = append(canonical, bson.E{Key: e.Key, Value: canonicalQuery(e.Value)})
+		}
+		return canonical
+	case []bson.M:
+		canonical := make(bson.A, 0, len(v))
+		for _, item := range v {
+			canonical = append(canonical, canonicalQuery(item))
+		}
+		return canonical
+	case bson.A:
+		return canonicalQuery([]interface{}(v))
+	case []interface{}:
+		canonical := make(bson.A, 0, len(v))
+		for _, item := range v {
+			canonical = append(canonical, canonicalQuery(item))
+		}
+		return canonical
+	default:
+		return query
+	}
+}
+
+// cacheEnabled tells whether query is served from cache. Snapshot
+// reads bypass cache as cached entries may come from other snapshots
+func (paging *pagingQuery) cacheEnabled() bool {
+	return paging.ResultCache != nil && !paging.SnapshotReads
+}
+
+// cacheHandler serves cacheable operations from cache of query before
+// they reach next handler and caches totals returned by next handler,
+// documents are cached once they have been read by documents
+func (paging *pagingQuery) cacheHandler(next Handler) Handler {
+	return func(op *Operation) error {
+		if !op.cacheable {
+			return next(op)
+		}
+		key, ok := operationKey(op)
+		if !ok {
+			return next(op)
+		}
+		if value, ok := paging.ResultCache.Get(key); ok {
+			switch v := value.(type) {
+			case int64:
+				if op.Kind == CountOperation {
+					op.Count, op.Cached = v, true
+					return nil
+				}
+			case []bson.Raw:
+				if op.Kind != CountOperation {
+					op.documents, op.Cached = v, true
+					return nil
+				}
+			}
+		}
+		if err := next(op); err != nil {
+			return err
+		}
+		if op.Kind == CountOperation {
+			paging.ResultCache.Set(key, op.Count)
+		} else {
+			op.cacheKey = &key
+		}
+		return nil
+	}
+}
+
+// documents returns raw documents of executed find or aggregate operation,
+// documents read from cursor are cached when operation is cacheable
+func (paging *pagingQuery) documents(op *Operation) ([]bson.Raw, error) {
+	if op.Cached {
+		return op.documents, nil
+	}
+	cursor := op.Cursor
+	defer cursor.Close(op.Ctx)
+	var data []bson.Raw
+	for cursor.Next(op.Ctx) {
+		data = append(data, append(bson.Raw{}, cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if op.cacheKey != nil && paging.cacheEnabled() {
+		paging.ResultCache.Set(*op.cacheKey, data)
+	}
+	return data, nil
+}
+
+// MemoryCache is in-memory Cache which evicts least recently used entries
+// once capacity is reached and expires totals and pages after their TTL
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	totalTTL time.Duration
+	pageTTL  time.Duration
+	entries  map[CacheKey]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key     CacheKey
+	value   interface{}
+	expires time.Time
+}
+
+// NewMemoryCache returns MemoryCache holding up to capacity entries,
+// totals expire after totalTTL and pages after pageTTL
+func NewMemoryCache(capacity int, totalTTL, pageTTL time.Duration) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		totalTTL: totalTTL,
+		pageTTL:  pageTTL,
+		entries:  make(map[CacheKey]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns cached value of key if it has not expired
+func (cache *MemoryCache) Get(key CacheKey) (interface{}, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !cache.now().Before(entry.expires) {
+		cache.remove(element)
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set caches value of key evicting least recently used entry if full
+func (cache *MemoryCache) Set(key CacheKey, value interface{}) {
+	ttl := cache.pageTTL
+	if key.Kind == CacheTotal {
+		ttl = cache.totalTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	expires := cache.now().Add(ttl)
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: expires})
+	for cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+	}
+}
+
+// Invalidate removes all entries of collection namespace
+func (cache *MemoryCache) Invalidate(collection string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for key, element := range cache.entries {
+		if key.Collection == collection {
+			cache.remove(element)
+		}
+	}
+}
+
+// Len returns number of cached entries including expired ones
+func (cache *MemoryCache) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.order.Len()
+}
+
+func (cache *MemoryCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*memoryCacheEntry).key)
+}
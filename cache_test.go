@@ -0,0 +1,150 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryCache(2, time.Minute, time.Second)
+	cache.now = func() time.Time { return now }
+
+	total := CacheKey{Kind: CacheTotal, Collection: "todo.a", Query: "1"}
+	page := CacheKey{Kind: CachePage, Collection: "todo.a", Query: "1"}
+	other := CacheKey{Kind: CachePage, Collection: "todo.b", Query: "1"}
+	cache.Set(total, int64(5))
+	cache.Set(page, []bson.Raw{})
+
+	// pages expire before totals
+	now = now.Add(2 * time.Second)
+	if _, ok := cache.Get(page); ok {
+		t.Errorf("expected page to be expired")
+	}
+	if value, ok := cache.Get(total); !ok || value.(int64) != 5 {
+		t.Errorf("expected total to be cached, got %v", value)
+	}
+
+	// least recently used entry is evicted
+	cache.Set(page, []bson.Raw{})
+	cache.Get(total)
+	cache.Set(other, []bson.Raw{})
+	if _, ok := cache.Get(page); ok {
+		t.Errorf("expected least recently used page to be evicted")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+
+	cache.Invalidate("todo.a")
+	if _, ok := cache.Get(total); ok {
+		t.Errorf("expected total of invalidated collection to be removed")
+	}
+	if _, ok := cache.Get(other); !ok {
+		t.Errorf("expected entry of other collection to be kept")
+	}
+}
+
+// cacheOperation stores value of operation in cache
+func cacheOperation(cache Cache, op Operation, value interface{}) {
+	if key, ok := operationKey(&op); ok {
+		cache.Set(key, value)
+	}
+}
+
+func TestOperationKey(t *testing.T) {
+	paging := New(nil).Limit(10).Page(2).Sort("title", 1).(*pagingQuery)
+	find := func(filter interface{}, skip int64) CacheKey {
+		key, _ := operationKey(&Operation{Kind: FindOperation, Filter: filter, FindOptions: paging.findOptions(skip)})
+		return key
+	}
+	a := find(bson.M{"status": "active", "title": bson.M{"$gt": "a"}}, 10)
+	b := find(map[string]interface{}{"title": bson.M{"$gt": "a"}, "status": "active"}, 10)
+	if a != b {
+		t.Errorf("expected equal keys for equal filters, got %v and %v", a, b)
+	}
+	if c := find(bson.M{"status": "active", "title": bson.M{"$gt": "a"}}, 20); c == a {
+		t.Errorf("expected different keys for different pages")
+	}
+	t1, _ := operationKey(&Operation{Kind: CountOperation, Filter: bson.M{"status": "active"}, CountOptions: options.Count()})
+	t2, _ := operationKey(&Operation{Kind: CountOperation, Filter: bson.M{"status": "active"}, CountOptions: options.Count(), FindOptions: paging.findOptions(20)})
+	if t1 != t2 || t1.Kind != CacheTotal {
+		t.Errorf("expected total key to ignore sort and page, got %v and %v", t1, t2)
+	}
+	if _, ok := operationKey(&Operation{Kind: PaginateOperation}); ok {
+		t.Errorf("expected no key of paginate operation")
+	}
+}
+
+func TestPagingQuery_FindCached(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute, time.Minute)
+	filter := bson.M{"status": "active"}
+	paging := New(nil).Limit(2).Page(2).Filter(filter).SetCache(cache).(*pagingQuery)
+	raw, _ := bson.Marshal(TodoTest{Title: "todo-2", Status: "active"})
+	cacheOperation(cache, Operation{Kind: CountOperation, Filter: filter, CountOptions: options.Count()}, int64(3))
+	cacheOperation(cache, Operation{Kind: FindOperation, Filter: filter, FindOptions: paging.findOptions(2)}, []bson.Raw{raw})
+
+	var todos []TodoTest
+	paginatedData, err := paging.Decode(&todos).Intercept(func(op *Operation, next Handler) error {
+		err := next(op)
+		if (op.Kind == CountOperation || op.Kind == FindOperation) && !op.Cached {
+			t.Errorf("expected %s operation to be served from cache", op.Kind)
+		}
+		return err
+	}).Find()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(todos) != 1 || todos[0].Title != "todo-2" {
+		t.Errorf("expected cached page to be decoded, got %v", todos)
+	}
+	if paginatedData.Pagination.Total != 3 || paginatedData.Pagination.From != 3 || paginatedData.Pagination.To != 3 {
+		t.Errorf("unexpected pagination data %+v", paginatedData.Pagination)
+	}
+}
+
+func TestPagingQuery_CacheScopedByInterceptor(t *testing.T) {
+	// client is not connected so that operations missing cache fail
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := client.Database("todo").Collection("todo")
+	cache := NewMemoryCache(10, time.Minute, time.Minute)
+	tenant := func(name string) Interceptor {
+		return func(op *Operation, next Handler) error {
+			if op.Kind == CountOperation || op.Kind == FindOperation {
+				op.Filter = bson.M{"$and": []interface{}{op.Filter, bson.M{"tenant": name}}}
+			}
+			return next(op)
+		}
+	}
+	query := func(name string, todos *[]TodoTest) PagingQuery {
+		return New(collection).Limit(10).Page(1).Filter(bson.M{}).Decode(todos).SetCache(cache).Intercept(tenant(name))
+	}
+
+	// entries of tenant a are keyed by filter of its interceptor
+	scoped := bson.M{"$and": []interface{}{bson.M{}, bson.M{"tenant": "a"}}}
+	raw, _ := bson.Marshal(TodoTest{Title: "todo-a"})
+	paging := query("a", nil).(*pagingQuery)
+	cacheOperation(cache, Operation{Kind: CountOperation, Collection: collection, Filter: scoped, CountOptions: options.Count()}, int64(42))
+	cacheOperation(cache, Operation{Kind: FindOperation, Collection: collection, Filter: scoped, FindOptions: paging.findOptions(0)}, []bson.Raw{raw})
+
+	var todos []TodoTest
+	paginatedData, err := query("a", &todos).Find()
+	if err != nil {
+		t.Fatalf("expected cached page of tenant a, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 42 || len(todos) != 1 || todos[0].Title != "todo-a" {
+		t.Errorf("expected cached page of tenant a, got %+v and %v", paginatedData.Pagination, todos)
+	}
+
+	// same query of tenant b misses cache and reaches mongo
+	todos = nil
+	if _, err := query("b", &todos).Find(); err == nil {
+		t.Errorf("expected tenant b not to be served from cache of tenant a, got %v", todos)
+	}
+}
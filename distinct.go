@@ -0,0 +1,54 @@
+package mongopagination
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"regexp"
+)
+
+// DistinctValue is value returned in data of Distinct along with
+// number of documents holding it
+type DistinctValue struct {
+	Value interface{} `bson:"_id" json:"value"`
+	Count int64       `bson:"count" json:"count"`
+}
+
+// Distinct paginates distinct values of field in documents matching filter
+// of query, each with its document count, decode Data into DistinctValue.
+// Values are limited to strings starting with prefix when it is set, the
+// anchored prefix search can use index of field. Sort fields of query order
+// values by _id or count, values are sorted by _id when none is set
+func (paging *pagingQuery) Distinct(field, prefix string) (*PaginatedData, error) {
+	if field == "" {
+		return nil, errors.New(DistinctFieldError)
+	}
+	if err := paging.unranked(); err != nil {
+		return nil, err
+	}
+	var match []interface{}
+	if paging.FilterQuery != nil {
+		match = append(match, paging.FilterQuery)
+	}
+	if prefix != "" {
+		match = append(match, bson.M{field: bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}})
+	}
+	filter := bson.M{}
+	if len(match) > 0 {
+		filter = bson.M{"$and": match}
+	}
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+	}
+
+	// filter is part of pipeline and stages added by query
+	// do not apply to grouped values
+	distinct := *paging
+	distinct.FilterQuery = nil
+	distinct.Unions = nil
+	distinct.Facets = nil
+	if len(distinct.SortFields) == 0 {
+		distinct.SortFields = bson.D{{Key: "_id", Value: 1}}
+	}
+	return distinct.Aggregate(pipeline)
+}
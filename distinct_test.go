@@ -0,0 +1,74 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_Distinct(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("DistinctTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	var docs []interface{}
+	for brand, n := range map[string]int{"a.c": 2, "a.cme": 1, "abc": 1, "acme": 3, "zeta": 1} {
+		for i := 0; i < n; i++ {
+			docs = append(docs, bson.M{"brand": brand, "status": "active"})
+		}
+	}
+	docs = append(docs, bson.M{"brand": "a.c", "status": "deleted"})
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	values := func(data []bson.Raw) []DistinctValue {
+		var values []DistinctValue
+		for _, raw := range data {
+			var value DistinctValue
+			if err := bson.Unmarshal(raw, &value); err != nil {
+				t.Fatal(err)
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	// prefix is matched literally, values are sorted by value
+	paginatedData, err := New(collection).Context(ctx).Limit(20).Page(1).Filter(bson.M{"status": "active"}).Distinct("brand", "a.c")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := []DistinctValue{{Value: "a.c", Count: 2}, {Value: "a.cme", Count: 1}}
+	if got := values(paginatedData.Data); paginatedData.Pagination.Total != 2 || !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v of 2 values, got %v of %d", expected, got, paginatedData.Pagination.Total)
+	}
+
+	// values sorted by count are paged
+	for page, expected := range map[int64][]DistinctValue{
+		1: {{Value: "acme", Count: 3}, {Value: "a.c", Count: 2}},
+		2: {{Value: "a.cme", Count: 1}, {Value: "abc", Count: 1}},
+		3: {{Value: "zeta", Count: 1}},
+	} {
+		paginatedData, err = New(collection).Context(ctx).Limit(2).Page(page).Filter(bson.M{"status": "active"}).
+			Sort("count", -1).Sort("_id", 1).Distinct("brand", "")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if paginatedData.Pagination.Total != 5 {
+			t.Errorf("expected 5 distinct values, got %+v", paginatedData.Pagination)
+		}
+		if got := values(paginatedData.Data); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected page %d to hold %v, got %v", page, expected, got)
+		}
+	}
+}
+
+func TestPagingQuery_DistinctField(t *testing.T) {
+	if _, err := New(nil).Limit(20).Page(1).Distinct("", ""); err == nil || err.Error() != DistinctFieldError {
+		t.Errorf("expected distinct field error, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package mongopagination
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ETag returns weak entity tag of paginated data computed from pagination
+// stats and documents. docs holds documents decoded by Find query, when it
+// is nil raw documents of Aggregate query are used instead
+func ETag(paginatedData *PaginatedData, docs interface{}) (string, error) {
+	hash := sha256.New()
+	pagination, err := json.Marshal(paginatedData.Pagination)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(pagination)
+	if docs != nil {
+		// encoding/json sorts map keys so that decoded maps hash stable
+		documents, err := json.Marshal(docs)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(documents)
+	} else {
+		for _, raw := range paginatedData.Data {
+			hash.Write(raw)
+		}
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, nil
+}
+
+// WriteConditional writes payload as JSON response with ETag of paginated
+// data. If request If-None-Match header matches the ETag it responds with
+// 304 Not Modified without body instead
+func WriteConditional(w http.ResponseWriter, r *http.Request, paginatedData *PaginatedData, docs interface{}, payload interface{}) error {
+	etag, err := ETag(paginatedData, docs)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// etagMatch weakly compares etag against list of If-None-Match tags
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
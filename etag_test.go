@@ -0,0 +1,70 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	raw, _ := bson.Marshal(bson.D{{Key: "title", Value: "todo-1"}})
+	aggregated := &PaginatedData{Data: []bson.Raw{raw}, Pagination: PaginationData{Total: 1, Page: 1}}
+	a, err := ETag(aggregated, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, _ := ETag(aggregated, nil); a != b {
+		t.Errorf("expected stable etag, got %s and %s", a, b)
+	}
+	aggregated.Pagination.Total = 2
+	if b, _ := ETag(aggregated, nil); a == b {
+		t.Errorf("expected etag to change with pagination")
+	}
+
+	found := &PaginatedData{Pagination: PaginationData{Total: 1, Page: 1}}
+	docs := []bson.M{{"title": "todo-1", "status": "active", "createdAt": 1}}
+	a, _ = ETag(found, docs)
+	for i := 0; i < 10; i++ {
+		// map iteration order must not affect etag
+		if b, _ := ETag(found, []bson.M{{"createdAt": 1, "status": "active", "title": "todo-1"}}); a != b {
+			t.Fatalf("expected stable etag for decoded maps, got %s and %s", a, b)
+		}
+	}
+	if b, _ := ETag(found, []bson.M{{"title": "todo-2"}}); a == b {
+		t.Errorf("expected etag to change with documents")
+	}
+}
+
+func TestWriteConditional(t *testing.T) {
+	paginatedData := &PaginatedData{Pagination: PaginationData{Total: 1, Page: 1}}
+	docs := []TodoTest{{Title: "todo-1"}}
+	etag, _ := ETag(paginatedData, docs)
+
+	tc := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{ifNoneMatch: "", status: http.StatusOK},
+		{ifNoneMatch: `W/"other"`, status: http.StatusOK},
+		{ifNoneMatch: etag, status: http.StatusNotModified},
+		{ifNoneMatch: `"other", ` + etag[2:], status: http.StatusNotModified},
+		{ifNoneMatch: "*", status: http.StatusNotModified},
+	}
+	for _, tt := range tc {
+		r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		if err := WriteConditional(w, r, paginatedData, docs, docs); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if w.Code != tt.status || w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %q: expected status %d with etag, got %d", tt.ifNoneMatch, tt.status, w.Code)
+		}
+		if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("expected empty body for not modified response")
+		}
+	}
+}
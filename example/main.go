@@ -70,11 +70,8 @@ func main() {
 			{"quantity", 1},
 		}
 		// Querying paginated data
-		// If you want to do some complex sort like sort by score(weight) for full text search fields you can do it easily
-		// sortValue := bson.M{
-		//		"$meta" : "textScore",
-		//	}
-		// paginatedData, err := paginate.New(collection).Context(ctx).Limit(limit).Page(page).Sort("score", sortValue)...
+		// For full text search sorted by score use Search, pass Pagination.Cursor of the page to After for the next one
+		// paginatedData, err := paginate.New(collection).Context(ctx).Limit(limit).Page(page).Search("coffee", "english")...
 		var products []Product
 		paginatedData, err := paginate.New(collection).Context(ctx).Limit(limit).Page(page).Sort("price", -1).Sort("quantity", -1).Select(projection).Filter(filter).Decode(&products).Find()
 		if err != nil {
@@ -0,0 +1,270 @@
+package mongopagination
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is output format of Export
+type ExportFormat int
+
+// Export formats
+const (
+	// NDJSON writes one relaxed extended JSON document per line
+	NDJSON ExportFormat = iota
+	// CSV writes one row per document with columns of ExportOptions
+	CSV
+)
+
+// ExportColumn maps dotted path of document field to CSV column
+type ExportColumn struct {
+	Header string
+	Path   string
+}
+
+// ExportProgress reports how far export has got
+type ExportProgress struct {
+	Documents int64
+	Batches   int64
+	// Cursor is continuation token of last written document,
+	// pass it as ExportOptions.Resume to continue failed export
+	// without writing any document twice
+	Cursor string
+}
+
+// ExportOptions configures Export
+type ExportOptions struct {
+	Format ExportFormat
+	// Columns of CSV export, defaults to fields of query projection
+	Columns []ExportColumn
+	// Progress is called after every written batch
+	Progress func(progress ExportProgress)
+	// Resume continues export after document of cursor token,
+	// CSV header is not written again
+	Resume string
+}
+
+// Export streams every document matching query to w in batches of query
+// limit, so memory use is bounded by batch size. Documents are walked the
+// same way as Pages. On failure the returned progress holds cursor of the
+// last written document to resume from, CSV rows are flushed one by one
+// so that a written row is never left in buffer
+func Export(query PagingQuery, w io.Writer, opts ExportOptions) (ExportProgress, error) {
+	var progress ExportProgress
+	paging, ok := query.(*pagingQuery)
+	if !ok {
+		return progress, errors.New(IteratorQueryError)
+	}
+	if err := paging.unranked(); err != nil {
+		return progress, err
+	}
+	from := scanPosition{}
+	if opts.Resume != "" {
+		var err error
+		if from, err = decodeScanPosition(opts.Resume, len(keysetSort(paging.SortFields))); err != nil {
+			return progress, err
+		}
+		progress.Cursor = opts.Resume
+	}
+
+	var write func(raw bson.Raw) error
+	switch opts.Format {
+	case NDJSON:
+		write = func(raw bson.Raw) error {
+			line, err := bson.MarshalExtJSON(raw, false, false)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(line, '\n'))
+			return err
+		}
+	case CSV:
+		columns := opts.Columns
+		if len(columns) == 0 {
+			columns = projectionColumns(paging.Project)
+		}
+		if len(columns) == 0 {
+			return progress, errors.New(ExportColumnsError)
+		}
+		writer := csv.NewWriter(w)
+		if opts.Resume == "" {
+			header := make([]string, len(columns))
+			for i, column := range columns {
+				header[i] = column.Header
+			}
+			if err := writer.Write(header); err != nil {
+				return progress, err
+			}
+		}
+		write = func(raw bson.Raw) error {
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				row[i] = csvValue(raw, column.Path)
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+		// header is flushed even if no document matches
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return progress, err
+		}
+	default:
+		return progress, errors.New(ExportFormatError)
+	}
+
+	// written is position of last written document, its cursor is
+	// encoded when progress is reported
+	sort := keysetSort(paging.SortFields)
+	written := from
+	var writeErr error
+	err := paging.scan(from, func(batch []bson.Raw, position scanPosition) bool {
+		for i, raw := range batch {
+			if writeErr = write(raw); writeErr != nil {
+				break
+			}
+			written.consumed = position.consumed - int64(len(batch)-1-i)
+			written.seek, _ = seekValues(sort, raw)
+			progress.Documents++
+		}
+		if progress.Documents > 0 {
+			progress.Cursor = encodeScanPosition(written)
+		}
+		if writeErr != nil {
+			return false
+		}
+		progress.Batches++
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		return true
+	})
+	if writeErr != nil {
+		return progress, writeErr
+	}
+	return progress, err
+}
+
+// projectionColumns returns columns of fields included by projection
+func projectionColumns(projection interface{}) []ExportColumn {
+	var keys []string
+	switch v := projection.(type) {
+	case bson.D:
+		for _, e := range v {
+			if direction(e.Value) > 0 {
+				keys = append(keys, e.Key)
+			}
+		}
+	case bson.M:
+		for key, value := range v {
+			if direction(value) > 0 {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+	}
+	columns := make([]ExportColumn, len(keys))
+	for i, key := range keys {
+		columns[i] = ExportColumn{Header: key, Path: key}
+	}
+	return columns
+}
+
+// csvValue returns value at dotted path of document formatted for CSV
+func csvValue(raw bson.Raw, path string) string {
+	value, err := raw.LookupErr(strings.Split(path, ".")...)
+	if err != nil {
+		return ""
+	}
+	switch value.Type {
+	case bson.TypeString:
+		return value.StringValue()
+	case bson.TypeInt32:
+		return strconv.FormatInt(int64(value.Int32()), 10)
+	case bson.TypeInt64:
+		return strconv.FormatInt(value.Int64(), 10)
+	case bson.TypeDouble:
+		return strconv.FormatFloat(value.Double(), 'f', -1, 64)
+	case bson.TypeBoolean:
+		return strconv.FormatBool(value.Boolean())
+	case bson.TypeObjectID:
+		return value.ObjectID().Hex()
+	case bson.TypeDateTime:
+		return value.Time().UTC().Format(time.RFC3339Nano)
+	case bson.TypeDecimal128:
+		return value.Decimal128().String()
+	case bson.TypeNull, bson.TypeUndefined:
+		return ""
+	default:
+		// embedded documents and arrays are written as JSON
+		var v interface{}
+		if err := value.Unmarshal(&v); err != nil {
+			return value.String()
+		}
+		encoded, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, false, false)
+		if err != nil {
+			return value.String()
+		}
+		// strip {"v": and closing brace of wrapping document
+		text := strings.TrimSpace(string(encoded))
+		text = strings.TrimPrefix(text, `{"v":`)
+		return strings.TrimSuffix(text, "}")
+	}
+}
+
+// encodeScanPosition returns continuation token of scan position
+func encodeScanPosition(position scanPosition) string {
+	doc := bson.D{{Key: "n", Value: position.consumed}}
+	if position.seek != nil {
+		doc = append(doc, bson.E{Key: "v", Value: bson.A(position.seek)})
+	}
+	encoded, _ := bson.Marshal(doc)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeScanPosition returns scan position of continuation token of scan
+// sorted on fields sort fields. Tokens come from clients, seek values
+// other than one scalar per sort field are rejected so that they cannot
+// turn into query operators of seek filter
+func decodeScanPosition(token string, fields int) (scanPosition, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return scanPosition{}, errors.New(CursorTokenError)
+	}
+	var doc struct {
+		N int64       `bson:"n"`
+		V primitive.A `bson:"v,omitempty"`
+	}
+	if err := bson.Unmarshal(encoded, &doc); err != nil || doc.N < 0 {
+		return scanPosition{}, errors.New(CursorTokenError)
+	}
+	position := scanPosition{consumed: doc.N}
+	if doc.V == nil {
+		return position, nil
+	}
+	if len(doc.V) != fields {
+		return scanPosition{}, errors.New(CursorTokenError)
+	}
+	for _, value := range doc.V {
+		if value == nil {
+			continue
+		}
+		t, _, err := bson.MarshalValue(value)
+		if err != nil || !seekable(t) {
+			return scanPosition{}, errors.New(CursorTokenError)
+		}
+	}
+	position.seek = []interface{}(doc.V)
+	return position, nil
+}
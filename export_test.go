@@ -0,0 +1,207 @@
+package mongopagination
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanPositionToken(t *testing.T) {
+	position := scanPosition{consumed: 250, seek: []interface{}{"done", int32(7)}}
+	decoded, err := decodeScanPosition(encodeScanPosition(position), 2)
+	if err != nil {
+		t.Fatalf("expected token to decode, got %v", err)
+	}
+	if !reflect.DeepEqual(decoded, position) {
+		t.Errorf("expected %+v, got %+v", position, decoded)
+	}
+
+	decoded, err = decodeScanPosition(encodeScanPosition(scanPosition{consumed: 3}), 2)
+	if err != nil || decoded.consumed != 3 || decoded.seek != nil {
+		t.Errorf("expected position without seek values, got %+v, %v", decoded, err)
+	}
+
+	decoded, err = decodeScanPosition(encodeScanPosition(scanPosition{consumed: 3, seek: []interface{}{nil, "id"}}), 2)
+	if err != nil || !reflect.DeepEqual(decoded.seek, []interface{}{nil, "id"}) {
+		t.Errorf("expected null seek value to decode, got %+v, %v", decoded, err)
+	}
+
+	// seek values are read by equality match of seek filter
+	tampered := []string{
+		encodeScanPosition(scanPosition{consumed: 3, seek: []interface{}{bson.M{"$ne": nil}, "id"}}),
+		encodeScanPosition(scanPosition{consumed: 3, seek: []interface{}{bson.A{1}, "id"}}),
+		encodeScanPosition(scanPosition{consumed: 3, seek: []interface{}{primitive.Regex{Pattern: "."}, "id"}}),
+		encodeScanPosition(scanPosition{consumed: 3, seek: []interface{}{"id"}}),
+	}
+	for _, token := range append([]string{"!", "AAAA"}, tampered...) {
+		if _, err := decodeScanPosition(token, 2); err == nil || err.Error() != CursorTokenError {
+			t.Errorf("expected cursor token error of %q, got %v", token, err)
+		}
+	}
+}
+
+func TestCSVValue(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	raw, _ := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "title", Value: "write, test"},
+		{Key: "count", Value: int32(3)},
+		{Key: "score", Value: 1.5},
+		{Key: "done", Value: true},
+		{Key: "at", Value: primitive.NewDateTimeFromTime(at)},
+		{Key: "owner", Value: bson.D{{Key: "name", Value: "ann"}}},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+		{Key: "empty", Value: nil},
+	})
+	cases := map[string]string{
+		"_id":        id.Hex(),
+		"title":      "write, test",
+		"count":      "3",
+		"score":      "1.5",
+		"done":       "true",
+		"at":         "2024-03-01T12:30:00Z",
+		"owner.name": "ann",
+		"owner":      `{"name":"ann"}`,
+		"tags":       `["a","b"]`,
+		"empty":      "",
+		"missing":    "",
+	}
+	for path, expected := range cases {
+		if value := csvValue(raw, path); value != expected {
+			t.Errorf("expected %q at %s, got %q", expected, path, value)
+		}
+	}
+}
+
+func TestProjectionColumns(t *testing.T) {
+	columns := projectionColumns(bson.M{"title": 1, "_id": 0, "status": 1})
+	expected := []ExportColumn{{Header: "status", Path: "status"}, {Header: "title", Path: "title"}}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %v, got %v", expected, columns)
+	}
+}
+
+func TestExportOptionsError(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := Export(New(nil), &out, ExportOptions{Format: CSV}); err == nil || err.Error() != ExportColumnsError {
+		t.Errorf("expected columns error, got %v", err)
+	}
+	if _, err := Export(New(nil), &out, ExportOptions{Format: ExportFormat(9)}); err == nil || err.Error() != ExportFormatError {
+		t.Errorf("expected format error, got %v", err)
+	}
+	if _, err := Export(New(nil), &out, ExportOptions{Resume: "!"}); err == nil || err.Error() != CursorTokenError {
+		t.Errorf("expected cursor token error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", out.String())
+	}
+}
+
+// failingWriter fails once it has passed writes calls on to w
+type failingWriter struct {
+	w      io.Writer
+	writes int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.writes == 0 {
+		return 0, errors.New("disk full")
+	}
+	f.writes--
+	return f.w.Write(p)
+}
+
+func TestExport(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("ExportTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	var docs []interface{}
+	for i := 1; i <= 7; i++ {
+		docs = append(docs, bson.D{{Key: "_id", Value: i}, {Key: "title", Value: fmt.Sprintf("t-%d", i)}, {Key: "rank", Value: 8 - i}})
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	// batches of 3 sorted by rank
+	query := func() PagingQuery {
+		return New(collection).Context(ctx).Limit(3).Filter(bson.M{}).Sort("rank", 1)
+	}
+	var lines, rows []string
+	for i := 7; i >= 1; i-- {
+		lines = append(lines, fmt.Sprintf(`{"_id":%d,"title":"t-%d","rank":%d}`, i, i, 8-i))
+		rows = append(rows, fmt.Sprintf("t-%d,%d", i, 8-i))
+	}
+	ndjson := strings.Join(lines, "\n") + "\n"
+	csv := "title,rank\n" + strings.Join(rows, "\n") + "\n"
+
+	var out bytes.Buffer
+	var reported []ExportProgress
+	progress, err := Export(query(), &out, ExportOptions{Format: NDJSON, Progress: func(progress ExportProgress) {
+		reported = append(reported, progress)
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.String() != ndjson {
+		t.Errorf("expected NDJSON\n%s\ngot\n%s", ndjson, out.String())
+	}
+	if progress.Documents != 7 || progress.Batches != 3 || len(reported) != 3 {
+		t.Errorf("expected 7 documents in 3 reported batches, got %+v reported %d times", progress, len(reported))
+	}
+	for i, documents := range []int64{3, 6, 7} {
+		if i < len(reported) && (reported[i].Documents != documents || reported[i].Batches != int64(i+1) || reported[i].Cursor == "") {
+			t.Errorf("expected progress of %d documents after batch %d, got %+v", documents, i+1, reported[i])
+		}
+	}
+
+	columns := []ExportColumn{{Header: "title", Path: "title"}, {Header: "rank", Path: "rank"}}
+	out.Reset()
+	if _, err := Export(query(), &out, ExportOptions{Format: CSV, Columns: columns}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.String() != csv {
+		t.Errorf("expected CSV\n%s\ngot\n%s", csv, out.String())
+	}
+
+	// export failing in the middle of second batch resumes after
+	// the last written document
+	cases := []struct {
+		opts     ExportOptions
+		writes   int
+		expected string
+	}{
+		{ExportOptions{Format: NDJSON}, 5, ndjson},
+		// header takes the first write
+		{ExportOptions{Format: CSV, Columns: columns}, 6, csv},
+	}
+	for _, c := range cases {
+		out.Reset()
+		progress, err := Export(query(), &failingWriter{w: &out, writes: c.writes}, c.opts)
+		if err == nil || err.Error() != "disk full" {
+			t.Fatalf("expected write error, got %v", err)
+		}
+		if progress.Documents != 5 || progress.Batches != 1 || progress.Cursor == "" {
+			t.Errorf("expected cursor after 5 written documents, got %+v", progress)
+		}
+		c.opts.Resume = progress.Cursor
+		progress, err = Export(query(), &out, c.opts)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if progress.Documents != 2 || out.String() != c.expected {
+			t.Errorf("expected resumed export\n%s\ngot\n%s", c.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package mongopagination
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+)
+
+// FacetKind is kind of counts computed by Facet
+type FacetKind int
+
+// Facet kinds
+const (
+	// TermsFacet counts documents per value of field
+	TermsFacet FacetKind = iota
+	// RangeFacet counts documents per range of field between boundaries
+	RangeFacet
+	// DateHistogramFacet counts documents per date truncated to unit
+	DateHistogramFacet
+)
+
+// Facet declares counts per value of field computed in $facet stage of
+// Aggregate over documents output by pipeline, regardless of page
+type Facet struct {
+	Name  string
+	Field string
+	Kind  FacetKind
+	// Size limits number of most frequent values of TermsFacet, all
+	// values are counted when it is 0
+	Size int64
+	// Boundaries are lower bounds of ranges of RangeFacet followed by
+	// upper bound of the last range, documents outside of them are
+	// counted under Other which must be set unless there are none
+	Boundaries []interface{}
+	Other      interface{}
+	// Unit of DateHistogramFacet such as day, week or month, it is
+	// computed with $dateTrunc which needs MongoDB 5.0
+	Unit string
+}
+
+// FacetBucket is count of documents of a facet value. Value is lower
+// bound of range of RangeFacet and start of period of DateHistogramFacet
+type FacetBucket struct {
+	Value interface{} `bson:"_id" json:"value"`
+	Count int64       `bson:"count" json:"count"`
+}
+
+// Terms returns facet counting documents per value of field, limited to
+// size most frequent values
+func Terms(name, field string, size int64) Facet {
+	return Facet{Name: name, Field: field, Kind: TermsFacet, Size: size}
+}
+
+// Ranges returns facet counting documents per range of field between
+// boundaries, documents outside of them are counted under other
+func Ranges(name, field string, boundaries []interface{}, other interface{}) Facet {
+	return Facet{Name: name, Field: field, Kind: RangeFacet, Boundaries: boundaries, Other: other}
+}
+
+// DateHistogram returns facet counting documents per unit of date field
+func DateHistogram(name, field, unit string) Facet {
+	return Facet{Name: name, Field: field, Kind: DateHistogramFacet, Unit: unit}
+}
+
+// AddFacets is function to add facets computed along with page of Aggregate
+func (paging *pagingQuery) AddFacets(facets ...Facet) PagingQuery {
+	paging.Facets = append(paging.Facets, facets...)
+	return paging
+}
+
+// pipeline returns $facet pipeline computing buckets of facet
+func (f Facet) pipeline() []bson.M {
+	field := "$" + f.Field
+	switch f.Kind {
+	case RangeFacet:
+		bucket := bson.M{
+			"groupBy":    field,
+			"boundaries": f.Boundaries,
+			"output":     bson.M{"count": bson.M{"$sum": 1}},
+		}
+		if f.Other != nil {
+			bucket["default"] = f.Other
+		}
+		return []bson.M{{"$bucket": bucket}}
+	case DateHistogramFacet:
+		return []bson.M{
+			{"$group": bson.M{
+				"_id":   bson.M{"$dateTrunc": bson.M{"date": field, "unit": f.Unit}},
+				"count": bson.M{"$sum": 1},
+			}},
+			{"$sort": bson.M{"_id": 1}},
+		}
+	default:
+		pipeline := []bson.M{{"$sortByCount": field}}
+		if f.Size > 0 {
+			pipeline = append(pipeline, bson.M{"$limit": f.Size})
+		}
+		return pipeline
+	}
+}
+
+// validateFacets checks that facet names do not clash with each other
+// or with data and total of $facet stage
+func validateFacets(facets []Facet) error {
+	names := map[string]bool{"data": true, "total": true}
+	for _, f := range facets {
+		if f.Name == "" || names[f.Name] || strings.HasPrefix(f.Name, "$") || strings.Contains(f.Name, ".") {
+			return errors.New(FacetNameError)
+		}
+		names[f.Name] = true
+	}
+	return nil
+}
+
+// decodeFacets returns buckets of facets of query in $facet output
+func (paging *pagingQuery) decodeFacets(output bson.Raw) map[string][]FacetBucket {
+	if len(paging.Facets) == 0 {
+		return nil
+	}
+	facets := make(map[string][]FacetBucket, len(paging.Facets))
+	for _, f := range paging.Facets {
+		buckets := []FacetBucket{}
+		if value, err := output.LookupErr(f.Name); err == nil {
+			_ = value.Unmarshal(&buckets)
+		}
+		facets[f.Name] = buckets
+	}
+	return facets
+}
@@ -0,0 +1,118 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFacetPipeline(t *testing.T) {
+	cases := []struct {
+		facet    Facet
+		expected []bson.M
+	}{
+		{Terms("status", "status", 5), []bson.M{{"$sortByCount": "$status"}, {"$limit": int64(5)}}},
+		{Terms("status", "status", 0), []bson.M{{"$sortByCount": "$status"}}},
+		{Ranges("price", "price", []interface{}{0, 10, 100}, "other"), []bson.M{{"$bucket": bson.M{
+			"groupBy":    "$price",
+			"boundaries": []interface{}{0, 10, 100},
+			"default":    "other",
+			"output":     bson.M{"count": bson.M{"$sum": 1}},
+		}}}},
+		{DateHistogram("created", "createdAt", "month"), []bson.M{
+			{"$group": bson.M{
+				"_id":   bson.M{"$dateTrunc": bson.M{"date": "$createdAt", "unit": "month"}},
+				"count": bson.M{"$sum": 1},
+			}},
+			{"$sort": bson.M{"_id": 1}},
+		}},
+	}
+	for _, c := range cases {
+		if pipeline := c.facet.pipeline(); !reflect.DeepEqual(pipeline, c.expected) {
+			t.Errorf("expected %v, got %v", c.expected, pipeline)
+		}
+	}
+}
+
+func TestDecodeFacets(t *testing.T) {
+	paging := New(nil).AddFacets(Terms("status", "status", 0), Terms("owner", "owner", 0)).(*pagingQuery)
+	output, _ := bson.Marshal(bson.M{
+		"data":   bson.A{},
+		"total":  bson.A{bson.M{"count": 134}},
+		"status": bson.A{bson.M{"_id": "active", "count": int32(120)}, bson.M{"_id": "archived", "count": int32(14)}},
+	})
+	facets := paging.decodeFacets(output)
+	expected := map[string][]FacetBucket{
+		"status": {{Value: "active", Count: 120}, {Value: "archived", Count: 14}},
+		"owner":  {},
+	}
+	if !reflect.DeepEqual(facets, expected) {
+		t.Errorf("expected %v, got %v", expected, facets)
+	}
+}
+
+func TestPagingQuery_AggregateFacets(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("FacetTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	docs := []interface{}{
+		bson.M{"status": "active", "price": 5, "createdAt": day(time.January, 15)},
+		bson.M{"status": "active", "price": 20, "createdAt": day(time.January, 20)},
+		bson.M{"status": "active", "price": 50, "createdAt": day(time.February, 3)},
+		bson.M{"status": "active", "price": 150, "createdAt": day(time.February, 10)},
+		bson.M{"status": "archived", "price": 8, "createdAt": day(time.March, 1)},
+		bson.M{"status": "deleted", "price": 1, "createdAt": day(time.January, 1)},
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	month := func(month time.Month) primitive.DateTime {
+		return primitive.NewDateTimeFromTime(time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC))
+	}
+
+	match := bson.M{"$match": bson.M{"status": bson.M{"$ne": "deleted"}}}
+	paginatedData, err := New(collection).Context(ctx).Limit(2).Page(1).Sort("price", 1).
+		AddFacets(
+			Terms("status", "status", 0),
+			Ranges("price", "price", []interface{}{0, 10, 100}, "other"),
+			DateHistogram("created", "createdAt", "month"),
+		).Aggregate(match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 5 || len(paginatedData.Data) != 2 {
+		t.Errorf("expected page of matched documents, got %+v with %d documents", paginatedData.Pagination, len(paginatedData.Data))
+	}
+
+	// facets count every matched document regardless of page
+	expected := map[string][]FacetBucket{
+		"status": {{Value: "active", Count: 4}, {Value: "archived", Count: 1}},
+		"price":  {{Value: int32(0), Count: 2}, {Value: int32(10), Count: 2}, {Value: "other", Count: 1}},
+		"created": {
+			{Value: month(time.January), Count: 2},
+			{Value: month(time.February), Count: 2},
+			{Value: month(time.March), Count: 1},
+		},
+	}
+	if !reflect.DeepEqual(paginatedData.Facets, expected) {
+		t.Errorf("expected facets %v, got %v", expected, paginatedData.Facets)
+	}
+}
+
+func TestPagingQuery_AggregateFacetName(t *testing.T) {
+	for _, name := range []string{"", "data", "a.b", "$x"} {
+		_, err := New(nil).Limit(10).Page(1).AddFacets(Terms(name, "status", 0)).Aggregate(bson.M{"$match": bson.M{}})
+		if err == nil || err.Error() != FacetNameError {
+			t.Errorf("expected facet name error of %q, got %v", name, err)
+		}
+	}
+}
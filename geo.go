@@ -0,0 +1,88 @@
+package mongopagination
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DistanceField is field holding distance in meters of documents found by Near
+const DistanceField = "distance"
+
+// earthRadius is radius of earth in meters used by mongo to convert
+// distances on sphere into radians
+const earthRadius = 6378100.0
+
+// GeoNear configures documents found by Near
+type GeoNear struct {
+	// Key is field holding GeoJSON location indexed with 2dsphere index
+	Key       string
+	Longitude float64
+	Latitude  float64
+	// MaxDistance limits distance in meters, it is not limited when 0
+	MaxDistance float64
+	// Query filters documents along with filter of query in Find mode
+	Query interface{}
+}
+
+// Near is function to find documents nearest to point first. $geoNear stage
+// opens the pipeline and holds distance of every document in DistanceField,
+// documents are sorted by distance followed by sort fields of query and _id
+// as tie-breaker. Pages can be continued with After, in Find mode $geoNear
+// then starts at distance of the last document instead of skipping nearer
+// ones. Like Search, it can only be paginated with Find or Aggregate
+func (paging *pagingQuery) Near(near GeoNear) PagingQuery {
+	point := bson.M{"type": "Point", "coordinates": bson.A{near.Longitude, near.Latitude}}
+	paging.Ranking = &ranking{
+		stages: func(filter interface{}, seek []interface{}) []bson.M {
+			geoNear := bson.M{
+				"near":          point,
+				"key":           near.Key,
+				"distanceField": DistanceField,
+				"spherical":     true,
+			}
+			if near.MaxDistance > 0 {
+				geoNear["maxDistance"] = near.MaxDistance
+			}
+			if query := andFilter(near.Query, filter); query != nil {
+				geoNear["query"] = query
+			}
+			if seek != nil {
+				// documents nearer than the last one of previous page
+				// are left out before they are sorted
+				geoNear["minDistance"] = seek[0]
+			}
+			return []bson.M{{"$geoNear": geoNear}}
+		},
+		countFilter: func(filter interface{}) interface{} {
+			// $geoNear cannot be counted, documents within max
+			// distance are counted instead
+			location := bson.M{"$exists": true}
+			if near.MaxDistance > 0 {
+				location = bson.M{"$geoWithin": bson.M{"$centerSphere": bson.A{
+					bson.A{near.Longitude, near.Latitude}, near.MaxDistance / earthRadius,
+				}}}
+			}
+			return andFilter(andFilter(near.Query, filter), bson.M{near.Key: location})
+		},
+		validate: func() error {
+			if near.Key == "" {
+				return errors.New(GeoKeyError)
+			}
+			return nil
+		},
+		field:     DistanceField,
+		direction: 1,
+	}
+	return paging
+}
+
+// andFilter returns filter matching both filters, nil filters are left out
+func andFilter(a, b interface{}) interface{} {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return bson.M{"$and": []interface{}{a, b}}
+}
@@ -0,0 +1,122 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_Near(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("GeoTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "location", Value: "2dsphere"}}}); err != nil {
+		t.Fatalf("Index create error. Error: %s", err.Error())
+	}
+	store := func(id string, east float64, open bool, brand string) bson.M {
+		location := bson.M{"type": "Point", "coordinates": bson.A{85.3 + east, 27.7}}
+		return bson.M{"_id": id, "location": location, "open": open, "brand": brand}
+	}
+	docs := []interface{}{
+		store("store-1", 0.001, true, "acme"),
+		store("store-2", 0.002, true, "acme"),
+		store("store-3", 0.003, true, "acme"),
+		// same distance as store-3, ordered after it by _id
+		store("store-3b", 0.003, true, "acme"),
+		store("store-4", 0.004, true, "acme"),
+		store("store-5", 0.005, true, "acme"),
+		store("closed", 0.0005, false, "acme"),
+		store("other", 0.0005, true, "other"),
+		// about 100km away, beyond max distance
+		store("far", 1, true, "acme"),
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	near := GeoNear{Key: "location", Longitude: 85.3, Latitude: 27.7, MaxDistance: 5000, Query: bson.M{"open": true}}
+	type nearStore struct {
+		ID       string  `bson:"_id"`
+		Distance float64 `bson:"distance"`
+	}
+	ids := func(stores []nearStore) []string {
+		var ids []string
+		for i, s := range stores {
+			if i > 0 && s.Distance < stores[i-1].Distance {
+				t.Errorf("expected stores sorted by distance, got %v", stores)
+			}
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	// find mode pages continue with cursor at distance of the last store
+	var stores []nearStore
+	paginatedData, err := New(collection).Context(ctx).Limit(3).Page(1).Filter(bson.M{"brand": "acme"}).Decode(&stores).Near(near).Find()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 6 || !paginatedData.Pagination.HasNext || paginatedData.Pagination.Cursor == "" {
+		t.Fatalf("expected first of 2 pages with cursor, got %+v", paginatedData.Pagination)
+	}
+	if got := ids(stores); !reflect.DeepEqual(got, []string{"store-1", "store-2", "store-3"}) {
+		t.Errorf("expected nearest stores first, got %v", got)
+	}
+	stores = nil
+	paginatedData, err = New(collection).Context(ctx).Limit(3).Page(1).Filter(bson.M{"brand": "acme"}).Decode(&stores).Near(near).
+		After(paginatedData.Pagination.Cursor).Find()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := ids(stores); !reflect.DeepEqual(got, []string{"store-3b", "store-4", "store-5"}) {
+		t.Errorf("expected stores after cursor, got %v", got)
+	}
+	if paginatedData.Pagination.Page != 2 || paginatedData.Pagination.HasNext || paginatedData.Pagination.Cursor != "" {
+		t.Errorf("expected last page without cursor, got %+v", paginatedData.Pagination)
+	}
+
+	// aggregate mode runs pipeline after $geoNear
+	match := bson.M{"$match": bson.M{"brand": "acme"}}
+	paginatedData, err = New(collection).Context(ctx).Limit(4).Page(1).Near(near).Aggregate(match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	decode := func(data []bson.Raw) []nearStore {
+		stores := make([]nearStore, len(data))
+		for i, raw := range data {
+			if err := bson.Unmarshal(raw, &stores[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return stores
+	}
+	if got := ids(decode(paginatedData.Data)); paginatedData.Pagination.Total != 6 || !reflect.DeepEqual(got, []string{"store-1", "store-2", "store-3", "store-3b"}) {
+		t.Errorf("expected nearest 4 of 6 stores, got %v of %d", got, paginatedData.Pagination.Total)
+	}
+	// facets count every store within max distance, not only those
+	// after cursor
+	brands := map[string][]FacetBucket{"brand": {{Value: "acme", Count: 6}}}
+	paginatedData, err = New(collection).Context(ctx).Limit(4).Page(1).Near(near).After(paginatedData.Pagination.Cursor).
+		AddFacets(Terms("brand", "brand", 0)).Aggregate(match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := ids(decode(paginatedData.Data)); paginatedData.Pagination.Total != 6 || !reflect.DeepEqual(got, []string{"store-4", "store-5"}) {
+		t.Errorf("expected stores after cursor of 6 stores, got %v of %d", got, paginatedData.Pagination.Total)
+	}
+	if !reflect.DeepEqual(paginatedData.Facets, brands) {
+		t.Errorf("expected facets %v after cursor, got %v", brands, paginatedData.Facets)
+	}
+}
+
+func TestPagingQuery_NearKey(t *testing.T) {
+	near := GeoNear{Longitude: 85.3, Latitude: 27.7}
+	if _, err := New(nil).Limit(10).Page(1).Near(near).Aggregate(bson.M{"$match": bson.M{}}); err == nil || err.Error() != GeoKeyError {
+		t.Errorf("expected geo key error, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GroupBy configures groups paginated by AggregateGroups
+type GroupBy struct {
+	// Key is field path documents are grouped on
+	Key string
+	// Items is number of documents returned per group
+	Items int64
+	// ItemSort orders documents of every group before the first Items
+	// are taken
+	ItemSort bson.D
+}
+
+// Group is group returned in data of AggregateGroups
+type Group struct {
+	Key   interface{} `bson:"_id" json:"key"`
+	Count int64       `bson:"count" json:"count"`
+	Items []bson.Raw  `bson:"items,omitempty" json:"items,omitempty"`
+}
+
+// AggregateGroups paginates groups of documents output by pipeline instead
+// of the documents themselves. Every group holds its key, document count
+// and the first documents of the group, pagination data counts groups.
+// Sort fields of query order groups by _id or count, groups are sorted
+// by _id when none is set. Documents of unions of query are grouped along
+// with documents of pipeline. Items are taken with $topN or $firstN which
+// need MongoDB 5.2
+func (paging *pagingQuery) AggregateGroups(group GroupBy, filters ...interface{}) (*PaginatedData, error) {
+	var pipeline []bson.M
+	for _, filter := range filters {
+		switch v := filter.(type) {
+		case []bson.M:
+			pipeline = append(pipeline, v...)
+		default:
+			pipeline = append(pipeline, filter.(bson.M))
+		}
+	}
+	// documents of unions are grouped along with documents of pipeline
+	pipeline = append(pipeline[:len(pipeline):len(pipeline)], paging.unionStages(pipeline)...)
+	pipeline = append(pipeline, groupStages(group)...)
+
+	grouped := *paging
+	// group key is checked along with other parameters by Aggregate
+	grouped.Grouping = &group
+	if len(grouped.SortFields) == 0 {
+		// groups come out of $group in no particular order
+		grouped.SortFields = bson.D{{Key: "_id", Value: 1}}
+	}
+	return grouped.Aggregate(pipeline)
+}
+
+// groupStages returns stages grouping documents on key of group
+func groupStages(group GroupBy) []bson.M {
+	fields := bson.M{
+		"_id":   "$" + group.Key,
+		"count": bson.M{"$sum": 1},
+	}
+	// only the first documents of every group are kept while grouping
+	switch {
+	case group.Items > 0 && len(group.ItemSort) > 0:
+		fields["items"] = bson.M{"$topN": bson.M{"n": group.Items, "sortBy": group.ItemSort, "output": "$$ROOT"}}
+	case group.Items > 0:
+		fields["items"] = bson.M{"$firstN": bson.M{"n": group.Items, "input": "$$ROOT"}}
+	}
+	return []bson.M{{"$group": fields}}
+}
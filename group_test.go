@@ -0,0 +1,130 @@
+package mongopagination
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_AggregateGroups(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("GroupTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	var docs []interface{}
+	for category, n := range map[string]int{"a": 5, "b": 2, "c": 1} {
+		for i := 1; i <= n; i++ {
+			docs = append(docs, bson.M{"title": fmt.Sprintf("%s-%d", category, i), "category": category, "price": i, "status": "active"})
+		}
+	}
+	docs = append(docs, bson.M{"title": "d-1", "category": "d", "price": 1, "status": "deleted"})
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+
+	match := bson.M{"$match": bson.M{"status": "active"}}
+	group := GroupBy{Key: "category", Items: 2, ItemSort: bson.D{{Key: "price", Value: -1}}}
+	paginatedData, err := New(collection).Context(ctx).Limit(2).Page(1).AggregateGroups(group, match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 3 || paginatedData.Pagination.TotalPage != 2 {
+		t.Errorf("expected 3 groups on 2 pages, got %+v", paginatedData.Pagination)
+	}
+	// groups are sorted by key, items of every group by price
+	expected := map[string][]string{"a": {"a-5", "a-4"}, "b": {"b-2", "b-1"}, "c": {"c-1"}}
+	counts := map[string]int64{"a": 5, "b": 2, "c": 1}
+	check := func(data []bson.Raw, keys ...string) {
+		if len(data) != len(keys) {
+			t.Fatalf("expected groups %v, got %d groups", keys, len(data))
+		}
+		for i, raw := range data {
+			var g Group
+			if err := bson.Unmarshal(raw, &g); err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, item := range g.Items {
+				titles = append(titles, item.Lookup("title").StringValue())
+			}
+			if g.Key != keys[i] || g.Count != counts[keys[i]] || !reflect.DeepEqual(titles, expected[keys[i]]) {
+				t.Errorf("expected group %s of %d with %v, got %v of %d with %v", keys[i], counts[keys[i]], expected[keys[i]], g.Key, g.Count, titles)
+			}
+		}
+	}
+	check(paginatedData.Data, "a", "b")
+
+	paginatedData, err = New(collection).Context(ctx).Limit(2).Page(2).AggregateGroups(group, match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	check(paginatedData.Data, "c")
+
+	// groups sorted by count
+	paginatedData, err = New(collection).Context(ctx).Limit(10).Page(1).Sort("count", 1).Sort("_id", 1).AggregateGroups(GroupBy{Key: "category"}, match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var keys []interface{}
+	for _, raw := range paginatedData.Data {
+		var g Group
+		bson.Unmarshal(raw, &g)
+		if g.Items != nil {
+			t.Errorf("expected counts only, got items %v", g.Items)
+		}
+		keys = append(keys, g.Key)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"c", "b", "a"}) {
+		t.Errorf("expected groups sorted by count, got %v", keys)
+	}
+
+	// documents of unions are counted in the same groups
+	archived := db.Collection("GroupTestArchived")
+	defer archived.Drop(ctx)
+	archivedDocs := []interface{}{
+		bson.M{"title": "a-6", "category": "a", "price": 6, "status": "active"},
+		bson.M{"title": "e-1", "category": "e", "price": 1, "status": "active"},
+	}
+	if _, err := archived.InsertMany(ctx, archivedDocs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	paginatedData, err = New(collection).Context(ctx).Limit(10).Page(1).Union("GroupTestArchived").AggregateGroups(GroupBy{Key: "category"}, match)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	merged := map[interface{}]int64{}
+	for _, raw := range paginatedData.Data {
+		var g Group
+		bson.Unmarshal(raw, &g)
+		merged[g.Key] += g.Count
+		if merged[g.Key] != g.Count {
+			t.Errorf("expected one group of key %v, got another one", g.Key)
+		}
+	}
+	expectedCounts := map[interface{}]int64{"a": 6, "b": 2, "c": 1, "e": 1}
+	if paginatedData.Pagination.Total != 4 || !reflect.DeepEqual(merged, expectedCounts) {
+		t.Errorf("expected groups %v, got %v of %d", expectedCounts, merged, paginatedData.Pagination.Total)
+	}
+}
+
+func TestPagingQuery_AggregateGroupsKey(t *testing.T) {
+	var validated error
+	query := New(nil).Limit(10).Page(1).Intercept(func(op *Operation, next Handler) error {
+		err := next(op)
+		if op.Kind == ValidateOperation {
+			validated = err
+		}
+		return err
+	})
+	if _, err := query.AggregateGroups(GroupBy{}); err == nil || err.Error() != GroupKeyError {
+		t.Errorf("expected group key error, got %v", err)
+	}
+	if validated == nil || validated.Error() != GroupKeyError {
+		t.Errorf("expected group key error to pass through interceptors, got %v", validated)
+	}
+}
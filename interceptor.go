@@ -0,0 +1,195 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+)
+
+// OperationKind is kind of mongo operation run by paging query
+type OperationKind string
+
+// Operation kinds
+const (
+	// ValidateOperation checks query parameters before PaginateOperation
+	ValidateOperation OperationKind = "validate"
+	// PaginateOperation wraps whole Find or Aggregate call, operations
+	// run to serve the page are executed with its context
+	PaginateOperation  OperationKind = "paginate"
+	CountOperation     OperationKind = "count"
+	FindOperation      OperationKind = "find"
+	AggregateOperation OperationKind = "aggregate"
+)
+
+// Operation holds mongo operation about to be executed by paging query.
+// Interceptors may mutate request fields before passing it on, result
+// fields are set once the operation has been executed. Filter, Pipeline
+// and paging fields of PaginateOperation are informational only
+type Operation struct {
+	Kind             OperationKind
+	Ctx              context.Context
+	Collection       *mongo.Collection
+	Filter           interface{}
+	Pipeline         interface{}
+	CountOptions     *options.CountOptions
+	FindOptions      *options.FindOptions
+	AggregateOptions *options.AggregateOptions
+
+	// paging fields of ValidateOperation and PaginateOperation
+	Strategy OperationKind
+	Page     int64
+	Limit    int64
+	Skip     int64
+	Sort     bson.D
+
+	// result of the operation, Cursor is nil when result is served
+	// from cache of query which is then reported by Cached
+	Count    int64
+	Cursor   *mongo.Cursor
+	Result   *PaginatedData
+	Duration time.Duration
+	Cached   bool
+
+	run func(ctx context.Context) (*PaginatedData, error)
+	// cacheable operations are served from cache of query
+	cacheable bool
+	documents []bson.Raw
+	cacheKey  *CacheKey
+}
+
+// Handler executes operation
+type Handler func(op *Operation) error
+
+// Interceptor wraps execution of operation. It can inspect or mutate
+// operation before calling next, veto it by returning an error without
+// calling next, and inspect the result and error once next returns
+type Interceptor func(op *Operation, next Handler) error
+
+var defaultInterceptors struct {
+	sync.RWMutex
+	chain []Interceptor
+}
+
+// SetDefaultInterceptors sets interceptors run by every paging query
+// before interceptors added to the query itself
+func SetDefaultInterceptors(interceptors ...Interceptor) {
+	defaultInterceptors.Lock()
+	defer defaultInterceptors.Unlock()
+	defaultInterceptors.chain = interceptors
+}
+
+// Intercept adds interceptors run around every mongo operation of query
+func (paging *pagingQuery) Intercept(interceptors ...Interceptor) PagingQuery {
+	paging.Interceptors = append(paging.Interceptors, interceptors...)
+	return paging
+}
+
+// execute runs operation through default and query interceptors
+func (paging *pagingQuery) execute(op *Operation) error {
+	defaultInterceptors.RLock()
+	chain := append([]Interceptor{}, defaultInterceptors.chain...)
+	defaultInterceptors.RUnlock()
+	chain = append(chain, paging.Interceptors...)
+
+	handler := Handler(executeOperation)
+	if paging.cacheEnabled() {
+		// cache is looked up once interceptors have changed operation
+		handler = paging.cacheHandler(handler)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		interceptor, next := chain[i], handler
+		handler = func(op *Operation) error {
+			return interceptor(op, next)
+		}
+	}
+	return handler(op)
+}
+
+// executeOperation runs operation against mongo
+func executeOperation(op *Operation) (err error) {
+	start := time.Now()
+	switch op.Kind {
+	case ValidateOperation, PaginateOperation:
+		op.Result, err = op.run(op.Ctx)
+	case CountOperation:
+		op.Count, err = op.Collection.CountDocuments(op.Ctx, op.Filter, op.CountOptions)
+	case FindOperation:
+		op.Cursor, err = op.Collection.Find(op.Ctx, op.Filter, op.FindOptions)
+	case AggregateOperation:
+		op.Cursor, err = op.Collection.Aggregate(op.Ctx, op.Pipeline, op.AggregateOptions)
+	}
+	op.Duration = time.Since(start)
+	return err
+}
+
+// validate runs check of query parameters as ValidateOperation
+func (paging *pagingQuery) validate(strategy OperationKind, check func() error) error {
+	op := paging.operation(ValidateOperation, strategy)
+	op.Filter = paging.FilterQuery
+	op.run = func(ctx context.Context) (*PaginatedData, error) {
+		return nil, check()
+	}
+	return paging.execute(op)
+}
+
+// paginate runs strategy serving page as PaginateOperation
+func (paging *pagingQuery) paginate(strategy OperationKind, query interface{}, run func(ctx context.Context) (*PaginatedData, error)) (*PaginatedData, error) {
+	op := paging.operation(PaginateOperation, strategy)
+	ctx, done, err := paging.withSession(op.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	op.Ctx = ctx
+	op.run = run
+	if strategy == AggregateOperation {
+		op.Pipeline = query
+	} else {
+		op.Filter = query
+	}
+	err = paging.execute(op)
+	snapshot := done()
+	if err != nil {
+		return nil, err
+	}
+	if op.Result != nil {
+		op.Result.Pagination.Snapshot = snapshot
+	}
+	return op.Result, nil
+}
+
+// operation returns operation of kind holding paging fields of query
+func (paging *pagingQuery) operation(kind, strategy OperationKind) *Operation {
+	return &Operation{
+		Kind:       kind,
+		Ctx:        paging.getContext(),
+		Collection: paging.Collection,
+		Strategy:   strategy,
+		Page:       paging.getPage(),
+		Limit:      paging.LimitCount,
+		Skip:       paging.getSkip(),
+		Sort:       paging.SortFields,
+	}
+}
+
+// count returns number of documents matching filter query
+func (paging *pagingQuery) count(ctx context.Context) (int64, error) {
+	collection, err := paging.collection()
+	if err != nil {
+		return 0, err
+	}
+	op := Operation{
+		Kind:         CountOperation,
+		Ctx:          ctx,
+		Collection:   collection,
+		Filter:       paging.FilterQuery,
+		CountOptions: options.Count(),
+		cacheable:    true,
+	}
+	if err := paging.execute(&op); err != nil {
+		return 0, err
+	}
+	return op.Count, nil
+}
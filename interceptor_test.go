@@ -0,0 +1,89 @@
+package mongopagination
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func TestPagingQuery_Intercept(t *testing.T) {
+	var calls []string
+	vetoErr := errors.New("vetoed")
+	SetDefaultInterceptors(func(op *Operation, next Handler) error {
+		if op.Kind == ValidateOperation {
+			return next(op)
+		}
+		calls = append(calls, "default")
+		if op.Kind == PaginateOperation {
+			return next(op)
+		}
+		op.Filter = bson.M{"$and": []interface{}{op.Filter, bson.M{"tenant": "acme"}}}
+		return next(op)
+	})
+	defer SetDefaultInterceptors()
+
+	var seen *Operation
+	var todos []TodoTest
+	_, err := New(nil).Limit(10).Page(1).Filter(bson.M{"status": "active"}).Decode(&todos).
+		Intercept(func(op *Operation, next Handler) error {
+			if op.Kind == ValidateOperation {
+				return next(op)
+			}
+			calls = append(calls, "query")
+			if op.Kind == PaginateOperation {
+				return next(op)
+			}
+			seen = op
+			return vetoErr
+		}).Find()
+	if !errors.Is(err, vetoErr) {
+		t.Fatalf("expected vetoed error, got %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"default", "query", "default", "query"}) {
+		t.Errorf("expected default interceptor to run before query interceptor, got %v", calls)
+	}
+	if seen.Kind != CountOperation || seen.Ctx == nil || seen.CountOptions == nil {
+		t.Errorf("expected count operation with context and options, got %+v", seen)
+	}
+	expected := bson.M{"$and": []interface{}{bson.M{"status": "active"}, bson.M{"tenant": "acme"}}}
+	if !reflect.DeepEqual(seen.Filter, expected) {
+		t.Errorf("expected filter mutated by default interceptor, got %v", seen.Filter)
+	}
+
+	// aggregate pipeline is passed to interceptors with $facet as last stage
+	_, err = New(nil).Limit(10).Page(2).Intercept(func(op *Operation, next Handler) error {
+		if op.Kind == ValidateOperation {
+			return next(op)
+		}
+		if op.Kind == PaginateOperation {
+			if op.Strategy != AggregateOperation || op.Skip != 10 {
+				t.Errorf("expected paginate operation of aggregate strategy skipping 10, got %+v", op)
+			}
+			return next(op)
+		}
+		seen = op
+		return vetoErr
+	}).Aggregate(bson.M{"$match": bson.M{}})
+	if !errors.Is(err, vetoErr) {
+		t.Fatalf("expected vetoed error, got %v", err)
+	}
+	pipeline := seen.Pipeline.([]bson.M)
+	if seen.Kind != AggregateOperation || len(pipeline) != 2 || pipeline[1]["$facet"] == nil {
+		t.Errorf("expected aggregate operation with $facet stage, got %+v", seen)
+	}
+}
+
+func TestPagingQuery_InterceptValidation(t *testing.T) {
+	var validationErr error
+	_, err := New(nil).Limit(10).Page(1).Intercept(func(op *Operation, next Handler) error {
+		err := next(op)
+		if op.Kind == ValidateOperation {
+			validationErr = err
+		}
+		return err
+	}).Find()
+	if err == nil || err != validationErr || err.Error() != DecodeEmptyError {
+		t.Errorf("expected validation error to be seen by interceptor, got %v and %v", err, validationErr)
+	}
+}
@@ -0,0 +1,297 @@
+package mongopagination
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"iter"
+	"strings"
+)
+
+// defaultBatchSize is number of documents fetched per batch when
+// iterating query without limit
+const defaultBatchSize int64 = 100
+
+// All returns iterator over every document matching query decoded into T,
+// starting at requested page. Limit sets number of documents fetched per
+// batch. Iteration stops with context error once query context is done
+func All[T any](query PagingQuery) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range Pages[T](query) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, doc := range page {
+				if !yield(doc, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pages returns iterator over batches of documents matching query decoded
+// into T. Batches following the first one seek past the last document of
+// previous batch on sort fields and _id instead of skipping, documents
+// are not counted
+func Pages[T any](query PagingQuery) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		paging, ok := query.(*pagingQuery)
+		if !ok {
+			yield(nil, errors.New(IteratorQueryError))
+			return
+		}
+		err := paging.scan(scanPosition{}, func(batch []bson.Raw, _ scanPosition) bool {
+			docs := make([]T, len(batch))
+			for i, raw := range batch {
+				if err := bson.Unmarshal(raw, &docs[i]); err != nil {
+					yield(nil, err)
+					return false
+				}
+			}
+			return yield(docs, nil)
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// scanPosition is position in result set of query reached by scan
+type scanPosition struct {
+	// consumed is number of documents consumed after skip of query
+	consumed int64
+	// seek holds sort values of last consumed document, it is nil
+	// when the document cannot be seeked past
+	seek []interface{}
+}
+
+// scan passes batches of raw documents matching query following position
+// from to yield until all documents are consumed or yield returns false
+func (paging *pagingQuery) scan(from scanPosition, yield func(batch []bson.Raw, position scanPosition) bool) error {
+	if err := paging.unranked(); err != nil {
+		return err
+	}
+	ctx := paging.getContext()
+	batchSize := paging.LimitCount
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	filter := paging.FilterQuery
+	if filter == nil {
+		filter = bson.M{}
+	}
+	sort := keysetSort(paging.SortFields)
+	position := from
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batchFilter := filter
+		batchSkip := paging.getSkip() + position.consumed
+		if position.seek != nil {
+			batchFilter = bson.M{"$and": []interface{}{filter, seekFilter(sort, position.seek)}}
+			batchSkip = 0
+		}
+		batch, err := paging.findBatch(ctx, batchFilter, sort, batchSkip, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		position.consumed += int64(len(batch))
+		// documents without seekable sort values are skipped instead
+		position.seek, _ = seekValues(sort, batch[len(batch)-1])
+		if !yield(batch, position) || int64(len(batch)) < batchSize {
+			return nil
+		}
+	}
+}
+
+// findBatch fetches batch of raw documents
+func (paging *pagingQuery) findBatch(ctx context.Context, filter interface{}, sort bson.D, skip, limit int64) ([]bson.Raw, error) {
+	opt := options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit)
+	if paging.Project != nil {
+		opt.SetProjection(paging.Project)
+	}
+	if paging.Collation != nil {
+		opt.SetCollation(paging.Collation)
+	}
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, err
+	}
+	op := Operation{
+		Kind:        FindOperation,
+		Ctx:         ctx,
+		Collection:  collection,
+		Filter:      filter,
+		FindOptions: opt,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, err
+	}
+	cursor := op.Cursor
+	defer cursor.Close(ctx)
+	var batch []bson.Raw
+	for cursor.Next(ctx) {
+		batch = append(batch, append(bson.Raw{}, cursor.Current...))
+	}
+	return batch, cursor.Err()
+}
+
+// keysetSort returns sort fields with _id appended as tie-breaker
+// so that every document has a distinct position
+func keysetSort(sortFields bson.D) bson.D {
+	sort := append(bson.D{}, sortFields...)
+	for _, field := range sort {
+		if field.Key == "_id" {
+			return sort
+		}
+	}
+	return append(sort, bson.E{Key: "_id", Value: 1})
+}
+
+// seekValues returns sort values of last document to seek past it, missing
+// and null values are returned as nil. It reports false when a sort value
+// of last is not seekable or sort is not a plain ascending or descending one
+func seekValues(sort bson.D, last bson.Raw) ([]interface{}, bool) {
+	values := make([]interface{}, len(sort))
+	for i, field := range sort {
+		if direction(field.Value) == 0 {
+			return nil, false
+		}
+		value, err := last.LookupErr(strings.Split(field.Key, ".")...)
+		if err != nil || value.Type == bson.TypeNull || value.Type == bson.TypeUndefined {
+			continue
+		}
+		if !seekable(value.Type) {
+			return nil, false
+		}
+		if err := value.Unmarshal(&values[i]); err != nil {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// seekable tells whether sort value of type can be seeked past. Documents,
+// arrays and regular expressions would be read as query by equality match
+func seekable(t bsontype.Type) bool {
+	switch t {
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble, bson.TypeDecimal128, bson.TypeString,
+		bson.TypeBinary, bson.TypeObjectID, bson.TypeBoolean, bson.TypeDateTime, bson.TypeTimestamp:
+		return true
+	}
+	return false
+}
+
+// seekFilter returns filter matching documents positioned after
+// document holding sort values in sort order
+func seekFilter(sort bson.D, values []interface{}) bson.M {
+	// (k1 after v1) or (k1 = v1 and k2 after v2) or ...
+	or := make([]bson.M, 0, len(sort))
+	for i, field := range sort {
+		condition := bson.M{}
+		for j := 0; j < i; j++ {
+			// null matches missing values as well
+			condition[sort[j].Key] = values[j]
+		}
+		after := seekAfter(field.Key, direction(field.Value), values[i])
+		if len(after) == 0 {
+			continue
+		}
+		if len(after) == 1 {
+			for key, value := range after[0] {
+				condition[key] = value
+			}
+		} else {
+			condition["$or"] = after
+		}
+		or = append(or, condition)
+	}
+	return bson.M{"$or": or}
+}
+
+// sortTypes holds $type aliases of every rank of typeRank, comparison
+// operators only match values of the same type so values of types
+// sorting after seek value are matched by type. Arrays sort by their
+// elements and types of unknown order are left out
+var sortTypes = map[int][]string{
+	1:  {"minKey"},
+	3:  {"number"},
+	4:  {"string", "symbol"},
+	5:  {"object"},
+	7:  {"binData"},
+	8:  {"objectId"},
+	9:  {"bool"},
+	10: {"date"},
+	11: {"timestamp"},
+	12: {"regex"},
+	14: {"maxKey"},
+}
+
+// seekAfter returns conditions matching values of key positioned after value
+// in sort direction, either by comparison or by type sorting after it
+func seekAfter(key string, direction int, value interface{}) []bson.M {
+	rank := typeRank(bson.TypeNull)
+	if value != nil {
+		t, _, err := bson.MarshalValue(value)
+		if err != nil {
+			return nil
+		}
+		rank = typeRank(t)
+	}
+	var after []bson.M
+	if value != nil {
+		operator := "$gt"
+		if direction < 0 {
+			operator = "$lt"
+		}
+		after = append(after, bson.M{key: bson.M{operator: value}})
+	}
+	var types []string
+	for r := typeRank(bson.TypeMinKey); r <= typeRank(bson.TypeMaxKey); r++ {
+		if (direction > 0 && r <= rank) || (direction < 0 && r >= rank) {
+			continue
+		}
+		if r == typeRank(bson.TypeNull) {
+			after = append(after, bson.M{key: nil})
+		}
+		types = append(types, sortTypes[r]...)
+	}
+	if len(types) > 0 {
+		after = append(after, bson.M{key: bson.M{"$type": types}})
+	}
+	return after
+}
+
+// direction returns 1 or -1 of ascending or descending sort value, 0 otherwise
+func direction(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return sign(int64(v))
+	case int32:
+		return sign(int64(v))
+	case int64:
+		return sign(v)
+	case float64:
+		return sign(int64(v))
+	}
+	return 0
+}
+
+func sign(v int64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+package mongopagination
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeekFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	last, _ := bson.Marshal(bson.M{"_id": id, "price": 10, "owner": bson.M{"name": "a"}, "deleted": nil})
+
+	sort := keysetSort(bson.D{{Key: "price", Value: -1}, {Key: "owner.name", Value: 1}})
+	values, ok := seekValues(sort, last)
+	if !ok {
+		t.Fatalf("expected seek values")
+	}
+	filter := seekFilter(sort, values)
+	expected := bson.M{"$or": []bson.M{
+		{"$or": []bson.M{
+			{"price": bson.M{"$lt": int32(10)}},
+			{"price": nil},
+			{"price": bson.M{"$type": []string{"minKey"}}},
+		}},
+		{"price": int32(10), "$or": []bson.M{
+			{"owner.name": bson.M{"$gt": "a"}},
+			{"owner.name": bson.M{"$type": []string{"object", "binData", "objectId", "bool", "date", "timestamp", "regex", "maxKey"}}},
+		}},
+		{"price": int32(10), "owner.name": "a", "$or": []bson.M{
+			{"_id": bson.M{"$gt": id}},
+			{"_id": bson.M{"$type": []string{"bool", "date", "timestamp", "regex", "maxKey"}}},
+		}},
+	}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %v, got %v", expected, filter)
+	}
+
+	// null and missing values sort before every other type
+	sort = keysetSort(bson.D{{Key: "deleted", Value: 1}})
+	values, ok = seekValues(sort, last)
+	if !ok || values[0] != nil {
+		t.Fatalf("expected null seek value, got %v", values)
+	}
+	filter = seekFilter(sort, values)
+	expected = bson.M{"$or": []bson.M{
+		{"deleted": bson.M{"$type": []string{"number", "string", "symbol", "object", "binData", "objectId", "bool", "date", "timestamp", "regex", "maxKey"}}},
+		{"deleted": nil, "$or": []bson.M{
+			{"_id": bson.M{"$gt": id}},
+			{"_id": bson.M{"$type": []string{"bool", "date", "timestamp", "regex", "maxKey"}}},
+		}},
+	}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %v, got %v", expected, filter)
+	}
+	if values, ok := seekValues(keysetSort(bson.D{{Key: "missing", Value: -1}}), last); !ok || values[0] != nil {
+		t.Errorf("expected missing seek value, got %v", values)
+	}
+
+	for _, sort := range []bson.D{
+		{{Key: "owner", Value: 1}},
+		{{Key: "score", Value: bson.M{"$meta": "textScore"}}},
+	} {
+		if _, ok := seekValues(keysetSort(sort), last); ok {
+			t.Errorf("expected no seek values for sort %v", sort)
+		}
+	}
+}
+
+func TestAll_MixedTypes(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("IteratorTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	docs := []interface{}{
+		bson.M{"title": "a", "price": 30},
+		bson.M{"title": "b", "price": 20.5},
+		bson.M{"title": "c", "price": int64(10)},
+		bson.M{"title": "d", "price": "cheap"},
+		bson.M{"title": "e", "price": nil},
+		bson.M{"title": "f"},
+		bson.M{"title": "g", "price": true},
+		bson.M{"title": "h", "price": 10},
+		bson.M{"title": "i"},
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+
+	for _, direction := range []int{1, -1} {
+		sort := bson.D{{Key: "price", Value: direction}, {Key: "_id", Value: 1}}
+		cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(sort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected []TodoTest
+		if err := cursor.All(ctx, &expected); err != nil {
+			t.Fatal(err)
+		}
+
+		// batches of 2 cross null, number, string and bool values
+		var titles, expectedTitles []string
+		for todo, err := range All[TodoTest](New(collection).Context(ctx).Limit(2).Filter(bson.M{}).Sort("price", direction)) {
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			titles = append(titles, todo.Title)
+		}
+		for _, todo := range expected {
+			expectedTitles = append(expectedTitles, todo.Title)
+		}
+		if len(expectedTitles) != len(docs) || !reflect.DeepEqual(titles, expectedTitles) {
+			t.Errorf("expected %v of sort direction %d, got %v", expectedTitles, direction, titles)
+		}
+	}
+}
+
+func TestKeysetSort(t *testing.T) {
+	if sort := keysetSort(bson.D{{Key: "_id", Value: -1}}); len(sort) != 1 {
+		t.Errorf("expected _id sort to be kept, got %v", sort)
+	}
+	sortFields := bson.D{{Key: "price", Value: 1}}
+	if sort := keysetSort(sortFields); len(sort) != 2 || sort[1].Key != "_id" || len(sortFields) != 1 {
+		t.Errorf("expected _id tie-breaker appended to copy, got %v", sort)
+	}
+}
+
+func TestPagesContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int
+	for page, err := range Pages[TodoTest](New(nil).Context(ctx).Limit(10)) {
+		calls++
+		if page != nil || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context canceled error, got %v and %v", page, err)
+		}
+	}
+	for _, err := range All[TodoTest](New(nil).Context(ctx)) {
+		calls++
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context canceled error, got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected single error from each iterator, got %d", calls)
+	}
+}
@@ -0,0 +1,437 @@
+package mongopagination
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strconv"
+	"strings"
+)
+
+// Merge returns page of documents matching query in every one of collections
+// merged in order of query sort, with total of all collections. Sort values
+// are compared in BSON order, collation is applied to queries but not to the
+// merge. Sort fields left out by projection are fetched for the merge and
+// removed from returned documents. Without cursor every collection is read
+// from its start up to the end of the page, so page N reads N times limit
+// documents of each collection. Pass PaginationData.Cursor of previous page
+// as cursor to continue from the position reached in every collection
+// instead, page of query is ignored then
+func Merge(query PagingQuery, collections []*mongo.Collection, cursor string) (*PaginatedData, error) {
+	paging, ok := query.(*pagingQuery)
+	if !ok {
+		return nil, errors.New(IteratorQueryError)
+	}
+	merged := *paging
+	if merged.Collection == nil && len(collections) > 0 {
+		merged.Collection = collections[0]
+	}
+	var offsets []int64
+	err := merged.validate(FindOperation, func() error {
+		if err := merged.validateQuery(true); err != nil {
+			return err
+		}
+		if err := merged.unranked(); err != nil {
+			return err
+		}
+		if merged.FilterQuery == nil {
+			return errors.New(NilFilterError)
+		}
+		if len(collections) == 0 {
+			return errors.New(MergeSourcesError)
+		}
+		if cursor == "" {
+			offsets = make([]int64, len(collections))
+			return nil
+		}
+		var err error
+		offsets, err = decodeMergeCursor(cursor, len(collections))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		merged.ByOffset = true
+		merged.OffsetCount = 0
+		for _, offset := range offsets {
+			merged.OffsetCount += offset
+		}
+	}
+	return merged.paginate(FindOperation, merged.FilterQuery, func(ctx context.Context) (*PaginatedData, error) {
+		return merged.merge(ctx, collections, offsets)
+	})
+}
+
+// merge fetches page of documents from collections starting at offsets
+// and merges them in sort order
+func (paging *pagingQuery) merge(ctx context.Context, collections []*mongo.Collection, offsets []int64) (*PaginatedData, error) {
+	sources := make([]pagingQuery, len(collections))
+	var count int64
+	for i, collection := range collections {
+		sources[i] = *paging
+		sources[i].Collection = collection
+		total, err := sources[i].count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		count += total
+	}
+	paginationInfo, err := clampPage(paging.paginator(count), paging.OutOfRange)
+	if err != nil {
+		return nil, err
+	}
+	reached := int64(0)
+	for _, offset := range offsets {
+		reached += offset
+	}
+	if paginationInfo.Offset < reached {
+		// clamped page lies before cursor, collections are read from start
+		offsets = make([]int64, len(collections))
+		reached = 0
+	}
+
+	// every collection may hold all documents up to the end of the page
+	discard := paginationInfo.Offset - reached
+	sort := keysetSort(paging.SortFields)
+	projection, added := sortProjection(paging.Project, sort)
+	batches := make([][]bson.Raw, len(collections))
+	if paginationInfo.Offset < count {
+		for i := range sources {
+			sources[i].Project = projection
+			if batches[i], err = sources[i].findMerged(ctx, sort, offsets[i], discard+paging.LimitCount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	data, positions := mergeBatches(sort, batches, discard, paging.LimitCount)
+	for i := range data {
+		if data[i], err = removeFields(data[i], added); err != nil {
+			return nil, err
+		}
+	}
+	if err := decodeRaw(data, paging.Decoder); err != nil {
+		return nil, err
+	}
+	paginationInfo.Count = int64(len(data))
+	result := PaginatedData{
+		Pagination: *paginationInfo.PaginationData(),
+	}
+	if result.Pagination.HasNext {
+		for i := range offsets {
+			offsets[i] += int64(positions[i])
+		}
+		result.Pagination.Cursor = encodeMergeCursor(offsets)
+	}
+	return &result, nil
+}
+
+// mergeBatches merges sorted batches and returns limit documents following
+// discarded ones along with number of documents taken from every batch
+func mergeBatches(sort bson.D, batches [][]bson.Raw, discard, limit int64) ([]bson.Raw, []int) {
+	var data []bson.Raw
+	positions := make([]int, len(batches))
+	for int64(len(data)) < limit {
+		next := -1
+		for i, batch := range batches {
+			if positions[i] == len(batch) {
+				continue
+			}
+			if next < 0 || compareDocuments(sort, batch[positions[i]], batches[next][positions[next]]) < 0 {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		if discard > 0 {
+			discard--
+		} else {
+			data = append(data, batches[next][positions[next]])
+		}
+		positions[next]++
+	}
+	return data, positions
+}
+
+// findMerged fetches up to limit raw documents of source after skip
+func (paging *pagingQuery) findMerged(ctx context.Context, sort bson.D, skip, limit int64) ([]bson.Raw, error) {
+	opt := paging.findOptions(skip).SetLimit(limit).SetSort(sort)
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, err
+	}
+	op := Operation{
+		Kind:        FindOperation,
+		Ctx:         ctx,
+		Collection:  collection,
+		Filter:      paging.FilterQuery,
+		FindOptions: opt,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, err
+	}
+	cursor := op.Cursor
+	defer cursor.Close(ctx)
+	var batch []bson.Raw
+	for cursor.Next(ctx) {
+		batch = append(batch, append(bson.Raw{}, cursor.Current...))
+	}
+	return batch, cursor.Err()
+}
+
+// sortProjection returns projection keeping sort fields along with fields
+// added or no longer excluded to that end
+func sortProjection(projection interface{}, sort bson.D) (interface{}, []string) {
+	var fields bson.D
+	switch v := projection.(type) {
+	case bson.M:
+		for key, value := range v {
+			fields = append(fields, bson.E{Key: key, Value: value})
+		}
+	case bson.D:
+		fields = v
+	default:
+		return projection, nil
+	}
+	inclusion := false
+	for _, e := range fields {
+		if e.Key != "_id" && included(e.Value) {
+			inclusion = true
+		}
+	}
+	kept := append(bson.D{}, fields...)
+	var added []string
+	for _, field := range sort {
+		i := projected(kept, field.Key)
+		switch {
+		case i >= 0 && included(kept[i].Value):
+		case i >= 0:
+			// excluded sort field or its parent is fetched again
+			added = append(added, kept[i].Key)
+			kept = append(kept[:i:i], kept[i+1:]...)
+		case inclusion && field.Key != "_id":
+			kept = append(kept, bson.E{Key: field.Key, Value: 1})
+			added = append(added, field.Key)
+		}
+	}
+	if len(added) == 0 {
+		return projection, nil
+	}
+	return kept, added
+}
+
+// projected returns index of projection of field or of its parent, -1
+// when field is not projected explicitly
+func projected(fields bson.D, key string) int {
+	for i, e := range fields {
+		if e.Key == key || strings.HasPrefix(key, e.Key+".") {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeFields returns copy of document without fields at paths,
+// embedded documents left empty are removed as well
+func removeFields(doc bson.Raw, paths []string) (bson.Raw, error) {
+	if len(paths) == 0 {
+		return doc, nil
+	}
+	var fields bson.D
+	if err := bson.Unmarshal(doc, &fields); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		fields = removeField(fields, strings.Split(path, "."))
+	}
+	return bson.Marshal(fields)
+}
+
+func removeField(fields bson.D, path []string) bson.D {
+	removed := make(bson.D, 0, len(fields))
+	for _, e := range fields {
+		if e.Key != path[0] {
+			removed = append(removed, e)
+			continue
+		}
+		if len(path) == 1 {
+			continue
+		}
+		if embedded, ok := e.Value.(bson.D); ok {
+			if embedded = removeField(embedded, path[1:]); len(embedded) > 0 {
+				removed = append(removed, bson.E{Key: e.Key, Value: embedded})
+			}
+			continue
+		}
+		removed = append(removed, e)
+	}
+	return removed
+}
+
+// compareDocuments compares documents on sort fields, it returns
+// negative value when a comes first in sort order
+func compareDocuments(sort bson.D, a, b bson.Raw) int {
+	for _, field := range sort {
+		path := strings.Split(field.Key, ".")
+		// missing values sort as null
+		x, _ := a.LookupErr(path...)
+		y, _ := b.LookupErr(path...)
+		if c := compareValues(x, y); c != 0 {
+			if direction(field.Value) < 0 {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares values in BSON comparison order of mongo,
+// values of different types are ordered by type
+func compareValues(a, b bson.RawValue) int {
+	if ra, rb := typeRank(a.Type), typeRank(b.Type); ra != rb {
+		return ra - rb
+	}
+	switch a.Type {
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble, bson.TypeDecimal128:
+		if x, ok := intValue(a); ok {
+			if y, ok := intValue(b); ok {
+				return compareOrdered(x, y)
+			}
+		}
+		return compareOrdered(numberValue(a), numberValue(b))
+	case bson.TypeString, bson.TypeSymbol:
+		return strings.Compare(stringValue(a), stringValue(b))
+	case bson.TypeObjectID:
+		x, y := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(x[:], y[:])
+	case bson.TypeBoolean:
+		return compareOrdered(boolRank(a.Boolean()), boolRank(b.Boolean()))
+	case bson.TypeDateTime:
+		return compareOrdered(a.DateTime(), b.DateTime())
+	case bson.TypeTimestamp:
+		at, ai := a.Timestamp()
+		bt, bi := b.Timestamp()
+		if at != bt {
+			return compareOrdered(at, bt)
+		}
+		return compareOrdered(ai, bi)
+	case 0, bson.TypeNull, bson.TypeUndefined, bson.TypeMinKey, bson.TypeMaxKey:
+		return 0
+	}
+	return bytes.Compare(a.Value, b.Value)
+}
+
+// typeRank returns rank of type in BSON comparison order
+func typeRank(t bsontype.Type) int {
+	switch t {
+	case bson.TypeMinKey:
+		return 1
+	case 0, bson.TypeNull, bson.TypeUndefined:
+		return 2
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble, bson.TypeDecimal128:
+		return 3
+	case bson.TypeString, bson.TypeSymbol:
+		return 4
+	case bson.TypeEmbeddedDocument:
+		return 5
+	case bson.TypeArray:
+		return 6
+	case bson.TypeBinary:
+		return 7
+	case bson.TypeObjectID:
+		return 8
+	case bson.TypeBoolean:
+		return 9
+	case bson.TypeDateTime:
+		return 10
+	case bson.TypeTimestamp:
+		return 11
+	case bson.TypeRegex:
+		return 12
+	case bson.TypeMaxKey:
+		return 14
+	}
+	return 13
+}
+
+func intValue(v bson.RawValue) (int64, bool) {
+	switch v.Type {
+	case bson.TypeInt32:
+		return int64(v.Int32()), true
+	case bson.TypeInt64:
+		return v.Int64(), true
+	}
+	return 0, false
+}
+
+func numberValue(v bson.RawValue) float64 {
+	if d, ok := v.Decimal128OK(); ok {
+		f, _ := strconv.ParseFloat(d.String(), 64)
+		return f
+	}
+	if f, ok := v.DoubleOK(); ok {
+		return f
+	}
+	i, _ := intValue(v)
+	return float64(i)
+}
+
+func stringValue(v bson.RawValue) string {
+	if s, ok := v.StringValueOK(); ok {
+		return s
+	}
+	s, _ := v.SymbolOK()
+	return s
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareOrdered[T int | int64 | uint32 | float64](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// encodeMergeCursor returns continuation token of offsets reached in
+// every merged collection
+func encodeMergeCursor(offsets []int64) string {
+	encoded, _ := bson.Marshal(bson.D{{Key: "o", Value: offsets}})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeMergeCursor returns offsets of continuation token of merge of
+// sources collections
+func decodeMergeCursor(token string, sources int) ([]int64, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New(CursorTokenError)
+	}
+	var doc struct {
+		O []int64 `bson:"o"`
+	}
+	if err := bson.Unmarshal(encoded, &doc); err != nil || len(doc.O) != sources {
+		return nil, errors.New(CursorTokenError)
+	}
+	for _, offset := range doc.O {
+		if offset < 0 {
+			return nil, errors.New(CursorTokenError)
+		}
+	}
+	return doc.O, nil
+}
@@ -0,0 +1,212 @@
+package mongopagination
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCompareDocuments(t *testing.T) {
+	docs := []bson.D{
+		{{Key: "title", Value: "b"}, {Key: "rank", Value: 2.5}},
+		{{Key: "title", Value: "a"}, {Key: "rank", Value: int32(2)}},
+		{{Key: "rank", Value: int64(3)}},
+		{{Key: "title", Value: "a"}, {Key: "rank", Value: int64(7)}},
+		{{Key: "title", Value: int32(9)}, {Key: "rank", Value: int32(1)}},
+	}
+	raws := make([]bson.Raw, len(docs))
+	for i, doc := range docs {
+		raws[i], _ = bson.Marshal(doc)
+	}
+	sortFields := bson.D{{Key: "title", Value: 1}, {Key: "rank", Value: -1}}
+	sort.SliceStable(raws, func(i, j int) bool {
+		return compareDocuments(sortFields, raws[i], raws[j]) < 0
+	})
+	var order []interface{}
+	for _, raw := range raws {
+		order = append(order, raw.Lookup("rank").String())
+	}
+	// missing sorts before numbers which sort before strings
+	expected := []interface{}{`{"$numberLong":"3"}`, `{"$numberInt":"1"}`, `{"$numberLong":"7"}`, `{"$numberInt":"2"}`, `{"$numberDouble":"2.5"}`}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+}
+
+func TestMergeCursor(t *testing.T) {
+	offsets, err := decodeMergeCursor(encodeMergeCursor([]int64{4, 0, 6}), 3)
+	if err != nil || !reflect.DeepEqual(offsets, []int64{4, 0, 6}) {
+		t.Errorf("expected offsets to round trip, got %v, %v", offsets, err)
+	}
+	for _, token := range []string{"!", encodeMergeCursor([]int64{1, 2}), encodeMergeCursor([]int64{1, -2, 3})} {
+		if _, err := decodeMergeCursor(token, 3); err == nil || err.Error() != CursorTokenError {
+			t.Errorf("expected cursor token error of %q, got %v", token, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collections := []*mongo.Collection{
+		client.Database("tenant_a").Collection("todo"),
+		client.Database("tenant_b").Collection("todo"),
+	}
+	totals := map[string]int64{"tenant_a.todo": 12, "tenant_b.todo": 9}
+	vetoErr := errors.New("vetoed")
+	type fetch struct {
+		namespace   string
+		skip, limit int64
+	}
+	var fetches []fetch
+	query := func() PagingQuery {
+		var todos []TodoTest
+		return New(nil).Limit(5).Page(3).Filter(bson.M{}).Sort("title", 1).Decode(&todos).Intercept(func(op *Operation, next Handler) error {
+			switch op.Kind {
+			case CountOperation:
+				op.Count = totals[CacheNamespace(op.Collection)]
+				return nil
+			case FindOperation:
+				fetches = append(fetches, fetch{CacheNamespace(op.Collection), *op.FindOptions.Skip, *op.FindOptions.Limit})
+				return vetoErr
+			}
+			return next(op)
+		})
+	}
+
+	// page without cursor fetches every document up to the end of the page
+	if _, err := Merge(query(), collections, ""); !errors.Is(err, vetoErr) {
+		t.Fatalf("expected vetoed error, got %v", err)
+	}
+	if !reflect.DeepEqual(fetches, []fetch{{"tenant_a.todo", 0, 15}}) {
+		t.Errorf("expected fetch of 15 documents, got %v", fetches)
+	}
+
+	// page of cursor continues at offsets of every collection
+	fetches = nil
+	if _, err := Merge(query(), collections, encodeMergeCursor([]int64{6, 4})); !errors.Is(err, vetoErr) {
+		t.Fatalf("expected vetoed error, got %v", err)
+	}
+	if !reflect.DeepEqual(fetches, []fetch{{"tenant_a.todo", 6, 5}}) {
+		t.Errorf("expected fetch of single page from cursor, got %v", fetches)
+	}
+
+	if _, err := Merge(query(), nil, ""); err == nil || err.Error() != MergeSourcesError {
+		t.Errorf("expected merge sources error, got %v", err)
+	}
+}
+
+func TestMergeBatches(t *testing.T) {
+	raw := func(title string) bson.Raw {
+		doc, _ := bson.Marshal(bson.M{"title": title})
+		return doc
+	}
+	batches := [][]bson.Raw{
+		{raw("a"), raw("d"), raw("e")},
+		{raw("b"), raw("c"), raw("f")},
+		nil,
+	}
+	data, positions := mergeBatches(bson.D{{Key: "title", Value: 1}}, batches, 2, 3)
+	var titles []string
+	for _, doc := range data {
+		titles = append(titles, doc.Lookup("title").StringValue())
+	}
+	if !reflect.DeepEqual(titles, []string{"c", "d", "e"}) {
+		t.Errorf("expected merged page c d e, got %v", titles)
+	}
+	if !reflect.DeepEqual(positions, []int{3, 2, 0}) {
+		t.Errorf("expected documents taken from every batch, got %v", positions)
+	}
+}
+
+func TestSortProjection(t *testing.T) {
+	sort := bson.D{{Key: "rank", Value: 1}, {Key: "owner.name", Value: 1}, {Key: "_id", Value: 1}}
+	cases := []struct {
+		projection interface{}
+		expected   interface{}
+		added      []string
+	}{
+		{bson.M{"title": 1}, bson.D{{Key: "title", Value: 1}, {Key: "rank", Value: 1}, {Key: "owner.name", Value: 1}}, []string{"rank", "owner.name"}},
+		{bson.D{{Key: "title", Value: 1}, {Key: "rank", Value: 1}, {Key: "owner", Value: 1}}, bson.D{{Key: "title", Value: 1}, {Key: "rank", Value: 1}, {Key: "owner", Value: 1}}, nil},
+		{bson.D{{Key: "rank", Value: 0}, {Key: "owner", Value: 0}, {Key: "body", Value: 0}}, bson.D{{Key: "body", Value: 0}}, []string{"rank", "owner"}},
+		{bson.D{{Key: "_id", Value: 0}, {Key: "rank", Value: 1}, {Key: "owner.name", Value: 1}}, bson.D{{Key: "rank", Value: 1}, {Key: "owner.name", Value: 1}}, []string{"_id"}},
+		{nil, nil, nil},
+	}
+	for _, c := range cases {
+		projection, added := sortProjection(c.projection, sort)
+		if !reflect.DeepEqual(projection, c.expected) || !reflect.DeepEqual(added, c.added) {
+			t.Errorf("expected %v adding %v, got %v adding %v", c.expected, c.added, projection, added)
+		}
+	}
+
+	doc, _ := bson.Marshal(bson.D{{Key: "title", Value: "a"}, {Key: "rank", Value: 1}, {Key: "owner", Value: bson.D{{Key: "name", Value: "x"}}}})
+	stripped, err := removeFields(doc, []string{"rank", "owner.name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := bson.Marshal(bson.D{{Key: "title", Value: "a"}})
+	if !bytes.Equal(stripped, expected) {
+		t.Errorf("expected %v, got %v", bson.Raw(expected), stripped)
+	}
+}
+
+func TestMerge_Projection(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	collections := []*mongo.Collection{db.Collection("MergeTestA"), db.Collection("MergeTestB")}
+	for i, collection := range collections {
+		defer collection.Drop(ctx)
+		var docs []interface{}
+		for rank := i; rank < 10; rank += 2 {
+			docs = append(docs, bson.M{"title": fmt.Sprintf("todo-%d", rank), "rank": rank})
+		}
+		if _, err := collection.InsertMany(ctx, docs); err != nil {
+			t.Fatalf("Data insert error. Error: %s", err.Error())
+		}
+	}
+
+	// rank is left out by projection but still orders the merge
+	query := func(todos *[]bson.M) PagingQuery {
+		return New(nil).Context(ctx).Limit(4).Page(1).Filter(bson.M{}).Select(bson.M{"_id": 0, "title": 1}).Sort("rank", -1).Decode(todos)
+	}
+	var titles []string
+	cursor := ""
+	for {
+		var todos []bson.M
+		paginatedData, err := Merge(query(&todos), collections, cursor)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if paginatedData.Pagination.Total != 10 {
+			t.Errorf("expected total of both collections, got %d", paginatedData.Pagination.Total)
+		}
+		for _, todo := range todos {
+			if len(todo) != 1 {
+				t.Errorf("expected only projected title, got %v", todo)
+			}
+			titles = append(titles, todo["title"].(string))
+		}
+		if cursor = paginatedData.Pagination.Cursor; cursor == "" {
+			break
+		}
+	}
+	var expected []string
+	for rank := 9; rank >= 0; rank-- {
+		expected = append(expected, fmt.Sprintf("todo-%d", rank))
+	}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("expected %v, got %v", expected, titles)
+	}
+}
@@ -0,0 +1,467 @@
+package mongopagination
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ODataQueryError is error message for malformed OData query options
+const ODataQueryError = "invalid odata query option"
+
+// ErrInvalidODataQuery is returned when OData query options cannot be parsed
+var ErrInvalidODataQuery = errors.New(ODataQueryError)
+
+// ODataQuery holds OData query options mapped onto mongo query
+type ODataQuery struct {
+	Top     *int64
+	Skip    *int64
+	OrderBy bson.D
+	Select  bson.D
+	Filter  bson.M
+	Count   bool
+}
+
+// ParseOData parses $top, $skip, $orderby, $select, $count and $filter query
+// options. Supported $filter subset is eq, ne, gt, ge, lt, le, and, or,
+// contains, startswith and parenthesis
+func ParseOData(values url.Values) (*ODataQuery, error) {
+	var query ODataQuery
+	var err error
+	if v := values.Get("$top"); v != "" {
+		if query.Top, err = parseODataInt("$top", v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("$skip"); v != "" {
+		if query.Skip, err = parseODataInt("$skip", v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("$count"); v != "" {
+		switch v {
+		case "true":
+			query.Count = true
+		case "false":
+		default:
+			return nil, errors.Wrapf(ErrInvalidODataQuery, "$count: %q", v)
+		}
+	}
+	if v := values.Get("$orderby"); v != "" {
+		for _, item := range strings.Split(v, ",") {
+			parts := strings.Fields(item)
+			if len(parts) == 0 || len(parts) > 2 {
+				return nil, errors.Wrapf(ErrInvalidODataQuery, "$orderby: %q", item)
+			}
+			field, err := parseODataField(parts[0])
+			if err != nil {
+				return nil, errors.Wrap(err, "$orderby")
+			}
+			direction := 1
+			if len(parts) == 2 {
+				switch strings.ToLower(parts[1]) {
+				case "asc":
+				case "desc":
+					direction = -1
+				default:
+					return nil, errors.Wrapf(ErrInvalidODataQuery, "$orderby: %q", item)
+				}
+			}
+			query.OrderBy = append(query.OrderBy, bson.E{Key: field, Value: direction})
+		}
+	}
+	if v := values.Get("$select"); v != "" {
+		for _, item := range strings.Split(v, ",") {
+			field, err := parseODataField(strings.TrimSpace(item))
+			if err != nil {
+				return nil, errors.Wrap(err, "$select")
+			}
+			query.Select = append(query.Select, bson.E{Key: field, Value: 1})
+		}
+	}
+	if v := values.Get("$filter"); v != "" {
+		if query.Filter, err = parseODataFilter(v); err != nil {
+			return nil, errors.Wrap(err, "$filter")
+		}
+	}
+	return &query, nil
+}
+
+// Apply sets options present in OData query on paging query, which is switched
+// to offset addressing starting at $skip or 0. $top=0 keeps limit of paging
+// query and returns only total of Find or Aggregate. For Aggregate queries
+// filter is not applied, pass Match() as pipeline stage instead
+func (query *ODataQuery) Apply(paging PagingQuery) PagingQuery {
+	if query.Top != nil {
+		if *query.Top == 0 {
+			if p, ok := paging.(*pagingQuery); ok {
+				p.EmptyPage = true
+				// limit of 0 falls back to default limit
+				paging = paging.Limit(p.LimitCount)
+			}
+		} else {
+			paging = paging.Limit(*query.Top)
+		}
+	}
+	var skip int64
+	if query.Skip != nil {
+		skip = *query.Skip
+	}
+	paging = paging.Offset(skip)
+	for _, sort := range query.OrderBy {
+		paging = paging.Sort(sort.Key, sort.Value)
+	}
+	if len(query.Select) > 0 {
+		paging = paging.Select(query.Select)
+	}
+	if query.Filter != nil {
+		paging = paging.Filter(query.Filter)
+	}
+	return paging
+}
+
+// Match returns $match stage of OData filter to be used in Aggregate
+func (query *ODataQuery) Match() bson.M {
+	filter := query.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+	return bson.M{"$match": filter}
+}
+
+// ODataResponse is OData compatible response body of paginated data
+type ODataResponse struct {
+	Count    *int64      `json:"@odata.count,omitempty"`
+	NextLink string      `json:"@odata.nextLink,omitempty"`
+	Value    interface{} `json:"value"`
+}
+
+// NewODataResponse builds OData response of paginated data. Value holds
+// decoded documents of Find query, when it is nil documents of Aggregate
+// query are used. requestURL is used to build @odata.nextLink
+func NewODataResponse(paginatedData *PaginatedData, value interface{}, requestURL *url.URL, count bool) *ODataResponse {
+	response := ODataResponse{Value: value}
+	if value == nil {
+		documents := make([]bson.M, 0, len(paginatedData.Data))
+		for _, raw := range paginatedData.Data {
+			var document bson.M
+			if err := bson.Unmarshal(raw, &document); err == nil {
+				documents = append(documents, document)
+			}
+		}
+		response.Value = documents
+	}
+	if count {
+		total := paginatedData.Pagination.Total
+		response.Count = &total
+	}
+	if requestURL != nil {
+		response.NextLink = ODataNextLink(requestURL, paginatedData.Pagination)
+	}
+	return &response
+}
+
+// ODataNextLink returns requestURL pointing to the records following
+// current page, or empty string when current page is the last one or
+// request only asks for count with $top=0
+func ODataNextLink(requestURL *url.URL, pagination PaginationData) string {
+	if !pagination.HasNext {
+		return ""
+	}
+	next := *requestURL
+	values := next.Query()
+	if top, err := strconv.ParseInt(values.Get("$top"), 10, 64); err == nil && top == 0 {
+		// no records were returned to continue from
+		return ""
+	}
+	values.Set("$skip", strconv.FormatInt(pagination.Offset+pagination.PerPage, 10))
+	values.Set("$top", strconv.FormatInt(pagination.PerPage, 10))
+	next.RawQuery = values.Encode()
+	return next.String()
+}
+
+func parseODataInt(option, value string) (*int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return nil, errors.Wrapf(ErrInvalidODataQuery, "%s: %q", option, value)
+	}
+	return &n, nil
+}
+
+// parseODataField converts OData property path to mongo dotted path
+func parseODataField(field string) (string, error) {
+	if field == "" {
+		return "", errors.Wrap(ErrInvalidODataQuery, "empty property")
+	}
+	for _, segment := range strings.Split(field, "/") {
+		if segment == "" || strings.HasPrefix(segment, "$") {
+			return "", errors.Wrapf(ErrInvalidODataQuery, "property %q", field)
+		}
+		for _, r := range segment {
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return "", errors.Wrapf(ErrInvalidODataQuery, "property %q", field)
+			}
+		}
+	}
+	return strings.Replace(field, "/", ".", -1), nil
+}
+
+// odataComparison maps OData comparison operators to mongo operators
+var odataComparison = map[string]string{
+	"eq": "$eq",
+	"ne": "$ne",
+	"gt": "$gt",
+	"ge": "$gte",
+	"lt": "$lt",
+	"le": "$lte",
+}
+
+type odataTokenKind int
+
+const (
+	odataIdent odataTokenKind = iota
+	odataString
+	odataNumber
+	odataOpen
+	odataClose
+	odataComma
+	odataEnd
+)
+
+type odataToken struct {
+	kind  odataTokenKind
+	value string
+}
+
+// odataFilterParser is recursive descent parser of $filter expression
+type odataFilterParser struct {
+	tokens []odataToken
+	pos    int
+}
+
+func parseODataFilter(filter string) (bson.M, error) {
+	tokens, err := tokenizeODataFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	parser := odataFilterParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != odataEnd {
+		return nil, errors.Wrapf(ErrInvalidODataQuery, "unexpected %q", parser.peek().value)
+	}
+	return expr, nil
+}
+
+func tokenizeODataFilter(filter string) ([]odataToken, error) {
+	var tokens []odataToken
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, odataToken{kind: odataOpen, value: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, odataToken{kind: odataClose, value: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, odataToken{kind: odataComma, value: ","})
+			i++
+		case r == '\'':
+			// quotes inside string literal are escaped by doubling them
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errors.Wrap(ErrInvalidODataQuery, "unterminated string")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, odataToken{kind: odataString, value: value.String()})
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, odataToken{kind: odataNumber, value: string(runes[start:i])})
+		case r == '_' || r == '/' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '/' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, odataToken{kind: odataIdent, value: string(runes[start:i])})
+		default:
+			return nil, errors.Wrapf(ErrInvalidODataQuery, "unexpected %q", string(r))
+		}
+	}
+	return append(tokens, odataToken{kind: odataEnd}), nil
+}
+
+func (parser *odataFilterParser) peek() odataToken {
+	return parser.tokens[parser.pos]
+}
+
+func (parser *odataFilterParser) next() odataToken {
+	token := parser.tokens[parser.pos]
+	if token.kind != odataEnd {
+		parser.pos++
+	}
+	return token
+}
+
+func (parser *odataFilterParser) expect(kind odataTokenKind, value string) error {
+	if token := parser.next(); token.kind != kind {
+		return errors.Wrapf(ErrInvalidODataQuery, "expected %q but got %q", value, token.value)
+	}
+	return nil
+}
+
+func (parser *odataFilterParser) keyword(word string) bool {
+	token := parser.peek()
+	if token.kind == odataIdent && strings.EqualFold(token.value, word) {
+		parser.pos++
+		return true
+	}
+	return false
+}
+
+func (parser *odataFilterParser) parseOr() (bson.M, error) {
+	return parser.parseLogical("or", "$or", parser.parseAnd)
+}
+
+func (parser *odataFilterParser) parseAnd() (bson.M, error) {
+	return parser.parseLogical("and", "$and", parser.parsePrimary)
+}
+
+// parseLogical parses operands joined by word and combines them with operator
+func (parser *odataFilterParser) parseLogical(word, operator string, operand func() (bson.M, error)) (bson.M, error) {
+	expr, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []bson.M{expr}
+	for parser.keyword(word) {
+		expr, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return bson.M{operator: exprs}, nil
+}
+
+func (parser *odataFilterParser) parsePrimary() (bson.M, error) {
+	token := parser.next()
+	switch token.kind {
+	case odataOpen:
+		expr, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expect(odataClose, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case odataIdent:
+		switch strings.ToLower(token.value) {
+		case "contains", "startswith":
+			if parser.peek().kind == odataOpen {
+				return parser.parseFunction(strings.ToLower(token.value))
+			}
+		}
+		field, err := parseODataField(token.value)
+		if err != nil {
+			return nil, err
+		}
+		op := parser.next()
+		operator, ok := odataComparison[strings.ToLower(op.value)]
+		if op.kind != odataIdent || !ok {
+			return nil, errors.Wrapf(ErrInvalidODataQuery, "unsupported operator %q", op.value)
+		}
+		value, err := parser.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{field: bson.M{operator: value}}, nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidODataQuery, "unexpected %q", token.value)
+	}
+}
+
+// parseFunction parses contains(field,'value') and startswith(field,'value')
+func (parser *odataFilterParser) parseFunction(name string) (bson.M, error) {
+	if err := parser.expect(odataOpen, "("); err != nil {
+		return nil, err
+	}
+	token := parser.next()
+	if token.kind != odataIdent {
+		return nil, errors.Wrapf(ErrInvalidODataQuery, "expected property but got %q", token.value)
+	}
+	field, err := parseODataField(token.value)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.expect(odataComma, ","); err != nil {
+		return nil, err
+	}
+	token = parser.next()
+	if token.kind != odataString {
+		return nil, errors.Wrapf(ErrInvalidODataQuery, "%s expects string but got %q", name, token.value)
+	}
+	if err := parser.expect(odataClose, ")"); err != nil {
+		return nil, err
+	}
+	pattern := regexp.QuoteMeta(token.value)
+	if name == "startswith" {
+		pattern = "^" + pattern
+	}
+	return bson.M{field: bson.M{"$regex": pattern}}, nil
+}
+
+func (parser *odataFilterParser) parseLiteral() (interface{}, error) {
+	token := parser.next()
+	switch token.kind {
+	case odataString:
+		return token.value, nil
+	case odataNumber:
+		if n, err := strconv.ParseInt(token.value, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(token.value, 64); err == nil {
+			return f, nil
+		}
+	case odataIdent:
+		switch strings.ToLower(token.value) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrInvalidODataQuery, "invalid literal %q", token.value)
+}
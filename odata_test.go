@@ -0,0 +1,142 @@
+package mongopagination
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseODataFilter(t *testing.T) {
+	tc := []struct {
+		filter   string
+		expected bson.M
+	}{
+		{
+			filter:   "status eq 'active'",
+			expected: bson.M{"status": bson.M{"$eq": "active"}},
+		}, {
+			filter:   "price gt 10 and price lt 20.5",
+			expected: bson.M{"$and": []bson.M{{"price": bson.M{"$gt": int64(10)}}, {"price": bson.M{"$lt": 20.5}}}},
+		}, {
+			filter: "status ne 'archived' or (deleted eq false and owner/name eq 'O''Brien')",
+			expected: bson.M{"$or": []bson.M{
+				{"status": bson.M{"$ne": "archived"}},
+				{"$and": []bson.M{{"deleted": bson.M{"$eq": false}}, {"owner.name": bson.M{"$eq": "O'Brien"}}}},
+			}},
+		}, {
+			filter:   "contains(name,'a.b') or startswith(name,'pro')",
+			expected: bson.M{"$or": []bson.M{{"name": bson.M{"$regex": `a\.b`}}, {"name": bson.M{"$regex": "^pro"}}}},
+		}, {
+			filter:   "parent eq null",
+			expected: bson.M{"parent": bson.M{"$eq": nil}},
+		},
+	}
+
+	for _, tt := range tc {
+		filter, err := parseODataFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", tt.filter, err)
+		}
+		if !reflect.DeepEqual(filter, tt.expected) {
+			t.Errorf("expected filter %q to be %v, got %v", tt.filter, tt.expected, filter)
+		}
+	}
+
+	for _, filter := range []string{
+		"status",
+		"status eq",
+		"status like 'a'",
+		"$where eq 'a'",
+		"(status eq 'a'",
+		"status eq 'a",
+		"contains(name, 1)",
+		"status eq 'a' xor b eq 1",
+	} {
+		if _, err := parseODataFilter(filter); !errors.Is(err, ErrInvalidODataQuery) {
+			t.Errorf("expected ErrInvalidODataQuery for %q, got %v", filter, err)
+		}
+	}
+}
+
+func TestParseOData(t *testing.T) {
+	values, _ := url.ParseQuery("$top=20&$skip=40&$count=true&$orderby=price desc,name&$select=name,owner/name&$filter=status eq 'active'")
+	query, err := ParseOData(values)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if *query.Top != 20 || *query.Skip != 40 || !query.Count {
+		t.Errorf("expected top 20, skip 40 and count, got %d, %d and %t", *query.Top, *query.Skip, query.Count)
+	}
+	if !reflect.DeepEqual(query.OrderBy, bson.D{{Key: "price", Value: -1}, {Key: "name", Value: 1}}) {
+		t.Errorf("unexpected orderby %v", query.OrderBy)
+	}
+	if !reflect.DeepEqual(query.Select, bson.D{{Key: "name", Value: 1}, {Key: "owner.name", Value: 1}}) {
+		t.Errorf("unexpected select %v", query.Select)
+	}
+
+	paging := query.Apply(New(nil)).(*pagingQuery)
+	if paging.LimitCount != 20 || !paging.ByOffset || paging.OffsetCount != 40 || len(paging.SortFields) != 2 {
+		t.Errorf("OData query was not applied on paging query: %+v", paging)
+	}
+
+	// request without $top and $skip starts at offset 0
+	values, _ = url.ParseQuery("$orderby=name")
+	query, _ = ParseOData(values)
+	paging = query.Apply(New(nil).Limit(10)).(*pagingQuery)
+	if err := paging.validateQuery(false); err != nil || paging.OffsetCount != 0 || paging.LimitCount != 10 {
+		t.Errorf("expected offset 0 and limit 10, got %d, %d and %v", paging.OffsetCount, paging.LimitCount, err)
+	}
+
+	// $top=0 only queries total
+	values, _ = url.ParseQuery("$top=0")
+	query, _ = ParseOData(values)
+	var products []TodoTest
+	vetoErr := errors.New("vetoed")
+	paginatedData, err := query.Apply(New(nil)).Filter(bson.M{}).Decode(&products).Intercept(func(op *Operation, next Handler) error {
+		switch op.Kind {
+		case CountOperation:
+			op.Count = 25
+			return nil
+		case FindOperation:
+			return vetoErr
+		}
+		return next(op)
+	}).Find()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 25 || paginatedData.Pagination.Count != 0 || products == nil || len(products) != 0 {
+		t.Errorf("expected empty page of 25 documents, got %+v and %v", paginatedData.Pagination, products)
+	}
+
+	for _, raw := range []string{"$top=-1", "$skip=a", "$count=yes", "$orderby=price up", "$select=a,,b"} {
+		values, _ := url.ParseQuery(raw)
+		if _, err := ParseOData(values); !errors.Is(err, ErrInvalidODataQuery) {
+			t.Errorf("expected ErrInvalidODataQuery for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestODataNextLink(t *testing.T) {
+	requestURL, _ := url.Parse("http://localhost/products?$top=10&$filter=price gt 5")
+	next := ODataNextLink(requestURL, PaginationData{Offset: 10, PerPage: 10, HasNext: true})
+	nextURL, _ := url.Parse(next)
+	if nextURL.Query().Get("$skip") != "20" || nextURL.Query().Get("$filter") != "price gt 5" {
+		t.Errorf("unexpected next link %s", next)
+	}
+	if next := ODataNextLink(requestURL, PaginationData{Offset: 10, PerPage: 10}); next != "" {
+		t.Errorf("expected no next link on last page, got %s", next)
+	}
+
+	// count only request keeps default limit but returns no records
+	requestURL, _ = url.Parse("http://localhost/products?$count=true&$top=0")
+	values, _ := url.ParseQuery(requestURL.RawQuery)
+	query, _ := ParseOData(values)
+	paging := query.Apply(New(nil).Limit(10)).(*pagingQuery)
+	pagination := paging.paginator(45).PaginationData()
+	if next := ODataNextLink(requestURL, *pagination); !pagination.HasNext || next != "" {
+		t.Errorf("expected no next link of count only request, got %s", next)
+	}
+}
@@ -0,0 +1,117 @@
+// Package otelpagination provides OpenTelemetry tracing for paginated
+// queries. It creates a span per Find or Aggregate call with child spans
+// for count and data phases
+package otelpagination
+
+import (
+	paginate "github.com/gobeam/mongo-go-pagination"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is instrumentation scope name of created tracer
+const ScopeName = "github.com/gobeam/mongo-go-pagination/otelpagination"
+
+// Attribute keys set on spans
+const (
+	CollectionKey = attribute.Key("db.collection.name")
+	DatabaseKey   = attribute.Key("db.namespace")
+	StrategyKey   = attribute.Key("pagination.strategy")
+	PageKey       = attribute.Key("pagination.page")
+	LimitKey      = attribute.Key("pagination.limit")
+	SkipKey       = attribute.Key("pagination.skip")
+	SortKey       = attribute.Key("pagination.sort")
+	TotalKey      = attribute.Key("pagination.total")
+	ResultSizeKey = attribute.Key("pagination.result_size")
+)
+
+type config struct {
+	provider trace.TracerProvider
+}
+
+// Option configures tracing interceptor
+type Option func(*config)
+
+// WithTracerProvider sets tracer provider used to create spans,
+// global tracer provider is used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// Interceptor returns interceptor tracing paginated queries. Add it before
+// other interceptors to have their work included in the spans
+func Interceptor(opts ...Option) paginate.Interceptor {
+	c := config{provider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	tracer := c.provider.Tracer(ScopeName)
+
+	return func(op *paginate.Operation, next paginate.Handler) error {
+		if op.Kind == paginate.ValidateOperation {
+			return next(op)
+		}
+		ctx, span := tracer.Start(op.Ctx, spanName(op),
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(startAttributes(op)...))
+		defer span.End()
+		op.Ctx = ctx
+
+		err := next(op)
+		switch {
+		case op.Kind == paginate.PaginateOperation && op.Result != nil:
+			span.SetAttributes(
+				TotalKey.Int64(op.Result.Pagination.Total),
+				ResultSizeKey.Int64(op.Result.Pagination.Count),
+			)
+		case op.Kind == paginate.CountOperation && err == nil:
+			span.SetAttributes(TotalKey.Int64(op.Count))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// spanName returns name of span, data phase of both
+// find and aggregate strategies is named data
+func spanName(op *paginate.Operation) string {
+	switch op.Kind {
+	case paginate.PaginateOperation:
+		return "pagination." + string(op.Strategy)
+	case paginate.CountOperation:
+		return "pagination.count"
+	default:
+		return "pagination.data"
+	}
+}
+
+func startAttributes(op *paginate.Operation) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if op.Collection != nil {
+		attrs = append(attrs,
+			CollectionKey.String(op.Collection.Name()),
+			DatabaseKey.String(op.Collection.Database().Name()),
+		)
+	}
+	if op.Kind != paginate.PaginateOperation {
+		return attrs
+	}
+	sortKeys := make([]string, 0, len(op.Sort))
+	for _, sort := range op.Sort {
+		sortKeys = append(sortKeys, sort.Key)
+	}
+	return append(attrs,
+		StrategyKey.String(string(op.Strategy)),
+		PageKey.Int64(op.Page),
+		LimitKey.Int64(op.Limit),
+		SkipKey.Int64(op.Skip),
+		SortKey.StringSlice(sortKeys),
+	)
+}
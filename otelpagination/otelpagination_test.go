@@ -0,0 +1,71 @@
+package otelpagination
+
+import (
+	"errors"
+	paginate "github.com/gobeam/mongo-go-pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	dataErr := errors.New("data failed")
+
+	// fake driver answering count and failing data phase
+	fake := func(op *paginate.Operation, next paginate.Handler) error {
+		switch op.Kind {
+		case paginate.ValidateOperation, paginate.PaginateOperation:
+			return next(op)
+		case paginate.CountOperation:
+			op.Count = 25
+			return nil
+		default:
+			return dataErr
+		}
+	}
+
+	var todos []bson.M
+	_, err := paginate.New(nil).Limit(10).Page(3).Sort("price", -1).Filter(bson.M{}).Decode(&todos).
+		Intercept(Interceptor(WithTracerProvider(provider)), fake).Find()
+	if !errors.Is(err, dataErr) {
+		t.Fatalf("expected data error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	count, data, parent := spans[0], spans[1], spans[2]
+	if parent.Name() != "pagination.find" || count.Name() != "pagination.count" || data.Name() != "pagination.data" {
+		t.Fatalf("unexpected span names %s, %s and %s", parent.Name(), count.Name(), data.Name())
+	}
+	for _, child := range []sdktrace.ReadOnlySpan{count, data} {
+		if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected %s to be child of %s", child.Name(), parent.Name())
+		}
+	}
+	if !hasAttribute(parent, PageKey.Int64(3)) || !hasAttribute(parent, SkipKey.Int64(20)) ||
+		!hasAttribute(parent, SortKey.StringSlice([]string{"price"})) || !hasAttribute(parent, StrategyKey.String("find")) {
+		t.Errorf("missing paging attributes on %v", parent.Attributes())
+	}
+	if !hasAttribute(count, TotalKey.Int64(25)) {
+		t.Errorf("missing total attribute on %v", count.Attributes())
+	}
+	if data.Status().Code != codes.Error || parent.Status().Code != codes.Error || count.Status().Code == codes.Error {
+		t.Errorf("expected error recorded on data and parent spans only")
+	}
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, expected attribute.KeyValue) bool {
+	for _, attr := range span.Attributes() {
+		if attr.Key == expected.Key && attr.Value.Emit() == expected.Value.Emit() {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,23 @@
 package mongopagination
 
 import (
+	"github.com/pkg/errors"
 	"math"
 )
 
+// OutOfRangePolicy decides how a request for a page beyond
+// the last page is served
+type OutOfRangePolicy int
+
+const (
+	// OutOfRangeEmpty serves an empty page for pages beyond the last page
+	OutOfRangeEmpty OutOfRangePolicy = iota
+	// OutOfRangeClamp serves the last page instead of the requested page
+	OutOfRangeClamp
+	// OutOfRangeError returns ErrPageOutOfRange for pages beyond the last page
+	OutOfRangeError
+)
+
 // Paginator struct for holding pagination info
 type Paginator struct {
 	TotalRecord int64 `json:"total_record"`
@@ -13,16 +27,27 @@ type Paginator struct {
 	Page        int64 `json:"page"`
 	PrevPage    int64 `json:"prev_page"`
 	NextPage    int64 `json:"next_page"`
+	Count       int64 `json:"count"`
 }
 
 // PaginationData struct for returning pagination stat
 type PaginationData struct {
-	Total     int64 `json:"total"`
-	Page      int64 `json:"page"`
-	PerPage   int64 `json:"perPage"`
-	Prev      int64 `json:"prev"`
-	Next      int64 `json:"next"`
-	TotalPage int64 `json:"totalPage"`
+	Total     int64  `json:"total"`
+	Page      int64  `json:"page"`
+	PerPage   int64  `json:"perPage"`
+	Prev      int64  `json:"prev"`
+	Next      int64  `json:"next"`
+	TotalPage int64  `json:"totalPage"`
+	Offset    int64  `json:"offset"`
+	HasPrev   bool   `json:"hasPrev"`
+	HasNext   bool   `json:"hasNext"`
+	First     int64  `json:"first"`
+	Last      int64  `json:"last"`
+	From      int64  `json:"from"`
+	To        int64  `json:"to"`
+	Count     int64  `json:"count"`
+	Snapshot  string `json:"snapshot,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
 }
 
 // PaginationData returns PaginationData struct which
@@ -35,49 +60,195 @@ func (p *Paginator) PaginationData() *PaginationData {
 		Prev:      0,
 		Next:      0,
 		TotalPage: p.TotalPage,
+		Offset:    p.Offset,
+		Count:     p.Count,
 	}
-	if p.Page != p.PrevPage && p.TotalRecord > 0 {
+	// derived from offset so that they also hold when
+	// offset is not a multiple of limit
+	data.HasPrev = p.TotalRecord > 0 && p.Offset > 0
+	data.HasNext = p.Offset+p.Limit < p.TotalRecord
+	if data.HasPrev {
 		data.Prev = p.PrevPage
 	}
-	if p.Page != p.NextPage && p.TotalRecord > 0 && p.Page <= p.TotalPage {
+	if data.HasNext {
 		data.Next = p.NextPage
 	}
+	if p.TotalRecord > 0 {
+		data.First = 1
+		data.Last = p.TotalPage
+	}
+	// item range is 1-based and only set when page has items
+	if p.Count > 0 {
+		data.From = p.Offset + 1
+		data.To = p.Offset + p.Count
+	}
 
 	return &data
 }
 
+// OutOfRange reports whether the paginator points past the last record.
+// The first page is always in range, even for an empty result set
+func (p *Paginator) OutOfRange() bool {
+	return p.Offset > 0 && p.Offset >= p.TotalRecord
+}
+
 // Paging returns Paginator struct which hold pagination
 // stats
 func Paging(p *pagingQuery, paginationInfo chan<- *Paginator, aggregate bool, aggCount int64) {
-	var paginator Paginator
-	var offset int64
 	var count int64
 	ctx := p.getContext()
 	if !aggregate {
-		count, _ = p.Collection.CountDocuments(ctx, p.FilterQuery)
+		count, _ = p.count(ctx)
 	} else {
 		count = aggCount
 	}
+	paginationInfo <- p.paginator(count)
+}
 
-	if p.PageCount > 0 {
-		offset = (p.PageCount - 1) * p.LimitCount
-	} else {
-		offset = 0
+// paginator computes pagination stats of query over count records. For
+// offset based query page is the one holding the first returned record
+func (paging *pagingQuery) paginator(count int64) *Paginator {
+	if !paging.ByOffset {
+		return newPaginator(count, paging.PageCount, paging.LimitCount)
 	}
+	paginator := newPaginator(count, paging.getPage(), paging.LimitCount)
+	paginator.Offset = paging.OffsetCount
+	if paginator.Offset > 0 {
+		// previous page holds the record before offset
+		paginator.PrevPage = (paginator.Offset-1)/paginator.Limit + 1
+		if paginator.PrevPage > paginator.TotalPage {
+			paginator.PrevPage = paginator.TotalPage
+		}
+	}
+	if paginator.Offset+paginator.Limit < count {
+		// next page holds the record following returned ones
+		paginator.NextPage = (paginator.Offset+paginator.Limit)/paginator.Limit + 1
+	}
+	return paginator
+}
+
+// newPaginator computes pagination stats for page of given limit
+// over count records
+func newPaginator(count, page, limit int64) *Paginator {
+	var paginator Paginator
 	paginator.TotalRecord = count
-	paginator.Page = p.PageCount
-	paginator.Offset = offset
-	paginator.Limit = p.LimitCount
-	paginator.TotalPage = int64(math.Ceil(float64(count) / float64(p.LimitCount)))
-	if p.PageCount > 1 {
-		paginator.PrevPage = p.PageCount - 1
-	} else {
-		paginator.PrevPage = p.PageCount
+	paginator.Page = page
+	paginator.Offset = getSkip(page, limit)
+	paginator.Limit = limit
+	paginator.TotalPage = int64(math.Ceil(float64(count) / float64(limit)))
+	switch {
+	case page > paginator.TotalPage && paginator.TotalPage > 0:
+		// pointing back to last available page
+		paginator.PrevPage = paginator.TotalPage
+	case page > 1:
+		paginator.PrevPage = page - 1
+	default:
+		paginator.PrevPage = page
 	}
-	if p.PageCount == paginator.TotalPage {
-		paginator.NextPage = p.PageCount
+	if page >= paginator.TotalPage {
+		paginator.NextPage = page
 	} else {
-		paginator.NextPage = p.PageCount + 1
+		paginator.NextPage = page + 1
+	}
+	return &paginator
+}
+
+// clampPage returns the page to serve for requested page according to policy.
+// It returns ErrPageOutOfRange if the policy forbids serving the page
+func clampPage(paginator *Paginator, policy OutOfRangePolicy) (*Paginator, error) {
+	if !paginator.OutOfRange() {
+		return paginator, nil
+	}
+	switch policy {
+	case OutOfRangeClamp:
+		page := paginator.TotalPage
+		if page < 1 {
+			page = 1
+		}
+		return newPaginator(paginator.TotalRecord, page, paginator.Limit), nil
+	case OutOfRangeError:
+		return nil, errors.Wrapf(ErrPageOutOfRange, "page %d of %d", paginator.Page, paginator.TotalPage)
+	default:
+		return paginator, nil
 	}
-	paginationInfo <- &paginator
+}
+
+// Ellipsis marks skipped pages in a page window
+const Ellipsis int64 = 0
+
+// PageWindow returns page numbers to render in a numbered pager. It holds
+// size pages centered around current page and edges pages at both ends of
+// the page range, with Ellipsis in place of every run of skipped pages,
+// e.g. 1 … 4 5 [6] 7 8 … 42 for size 5 and edges 1
+func (p *PaginationData) PageWindow(size, edges int64) []int64 {
+	total := p.TotalPage
+	if total < 1 {
+		return nil
+	}
+	if size < 1 {
+		size = 1
+	}
+	if edges < 0 {
+		edges = 0
+	}
+	current := p.Page
+	if current < 1 {
+		current = 1
+	} else if current > total {
+		current = total
+	}
+
+	// sliding window around current page kept inside page range
+	start := current - (size-1)/2
+	end := start + size - 1
+	if end > total {
+		start -= end - total
+		end = total
+	}
+	if start < 1 {
+		end += 1 - start
+		start = 1
+	}
+	if end > total {
+		end = total
+	}
+
+	var pages []int64
+	var last int64
+	add := func(page int64) {
+		if page <= last || page < 1 || page > total {
+			return
+		}
+		switch {
+		case page-last == 2:
+			// single skipped page is shown instead of ellipsis
+			pages = append(pages, last+1)
+		case page-last > 2:
+			pages = append(pages, Ellipsis)
+		}
+		pages = append(pages, page)
+		last = page
+	}
+	for page := int64(1); page <= edges; page++ {
+		add(page)
+	}
+	for page := start; page <= end; page++ {
+		add(page)
+	}
+	for page := total - edges + 1; page <= total; page++ {
+		add(page)
+	}
+	switch {
+	case total-last == 1:
+		pages = append(pages, total)
+	case total-last > 1:
+		pages = append(pages, Ellipsis)
+	}
+	return pages
+}
+
+// PageWindow returns page numbers to render in a numbered pager,
+// see PaginationData.PageWindow
+func (p *Paginator) PageWindow(size, edges int64) []int64 {
+	return p.PaginationData().PageWindow(size, edges)
 }
@@ -0,0 +1,240 @@
+package mongopagination
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPaging(t *testing.T) {
+	tc := []struct {
+		name     string
+		count    int64
+		page     int64
+		limit    int64
+		returned int64
+		expected PaginationData
+	}{
+		{
+			name:     "empty result",
+			count:    0,
+			page:     1,
+			limit:    10,
+			returned: 0,
+			expected: PaginationData{Total: 0, Page: 1, PerPage: 10, Prev: 0, Next: 0, TotalPage: 0, Offset: 0},
+		}, {
+			name:     "single page",
+			count:    5,
+			page:     1,
+			limit:    10,
+			returned: 5,
+			expected: PaginationData{Total: 5, Page: 1, PerPage: 10, Prev: 0, Next: 0, TotalPage: 1, Offset: 0,
+				First: 1, Last: 1, From: 1, To: 5, Count: 5},
+		}, {
+			name:     "first page",
+			count:    25,
+			page:     1,
+			limit:    10,
+			returned: 10,
+			expected: PaginationData{Total: 25, Page: 1, PerPage: 10, Prev: 0, Next: 2, TotalPage: 3, Offset: 0,
+				HasNext: true, First: 1, Last: 3, From: 1, To: 10, Count: 10},
+		}, {
+			name:     "middle page",
+			count:    25,
+			page:     2,
+			limit:    10,
+			returned: 10,
+			expected: PaginationData{Total: 25, Page: 2, PerPage: 10, Prev: 1, Next: 3, TotalPage: 3, Offset: 10,
+				HasPrev: true, HasNext: true, First: 1, Last: 3, From: 11, To: 20, Count: 10},
+		}, {
+			name:     "last page",
+			count:    25,
+			page:     3,
+			limit:    10,
+			returned: 5,
+			expected: PaginationData{Total: 25, Page: 3, PerPage: 10, Prev: 2, Next: 0, TotalPage: 3, Offset: 20,
+				HasPrev: true, First: 1, Last: 3, From: 21, To: 25, Count: 5},
+		}, {
+			name:     "exactly filled last page",
+			count:    30,
+			page:     3,
+			limit:    10,
+			returned: 10,
+			expected: PaginationData{Total: 30, Page: 3, PerPage: 10, Prev: 2, Next: 0, TotalPage: 3, Offset: 20,
+				HasPrev: true, First: 1, Last: 3, From: 21, To: 30, Count: 10},
+		}, {
+			name:     "page beyond last page",
+			count:    25,
+			page:     7,
+			limit:    10,
+			returned: 0,
+			expected: PaginationData{Total: 25, Page: 7, PerPage: 10, Prev: 3, Next: 0, TotalPage: 3, Offset: 60,
+				HasPrev: true, First: 1, Last: 3},
+		}, {
+			name:     "page beyond empty result",
+			count:    0,
+			page:     2,
+			limit:    10,
+			returned: 0,
+			expected: PaginationData{Total: 0, Page: 2, PerPage: 10, Prev: 0, Next: 0, TotalPage: 0, Offset: 10},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			paging := &pagingQuery{PageCount: tt.page, LimitCount: tt.limit}
+			paginationInfoChan := make(chan *Paginator, 1)
+			Paging(paging, paginationInfoChan, true, tt.count)
+			paginationInfo := <-paginationInfoChan
+			if paginationInfo.Offset != getSkip(tt.page, tt.limit) {
+				t.Errorf("expected offset to be %d, got %d", getSkip(tt.page, tt.limit), paginationInfo.Offset)
+			}
+			paginationInfo.Count = tt.returned
+			if data := paginationInfo.PaginationData(); *data != tt.expected {
+				t.Errorf("expected pagination data to be %+v, got %+v", tt.expected, *data)
+			}
+		})
+	}
+}
+
+func TestPagingByOffset(t *testing.T) {
+	tc := []struct {
+		name     string
+		count    int64
+		offset   int64
+		limit    int64
+		returned int64
+		expected PaginationData
+	}{
+		{
+			name:     "aligned offset",
+			count:    25,
+			offset:   10,
+			limit:    10,
+			returned: 10,
+			expected: PaginationData{Total: 25, Page: 2, PerPage: 10, Prev: 1, Next: 3, TotalPage: 3, Offset: 10,
+				HasPrev: true, HasNext: true, First: 1, Last: 3, From: 11, To: 20, Count: 10},
+		}, {
+			name:     "unaligned offset on first page",
+			count:    25,
+			offset:   5,
+			limit:    10,
+			returned: 10,
+			expected: PaginationData{Total: 25, Page: 1, PerPage: 10, Prev: 1, Next: 2, TotalPage: 3, Offset: 5,
+				HasPrev: true, HasNext: true, First: 1, Last: 3, From: 6, To: 15, Count: 10},
+		}, {
+			name:     "unaligned offset reaching end",
+			count:    25,
+			offset:   17,
+			limit:    10,
+			returned: 8,
+			expected: PaginationData{Total: 25, Page: 2, PerPage: 10, Prev: 2, Next: 0, TotalPage: 3, Offset: 17,
+				HasPrev: true, First: 1, Last: 3, From: 18, To: 25, Count: 8},
+		}, {
+			name:     "offset beyond last record",
+			count:    25,
+			offset:   25,
+			limit:    10,
+			returned: 0,
+			expected: PaginationData{Total: 25, Page: 3, PerPage: 10, Prev: 3, Next: 0, TotalPage: 3, Offset: 25,
+				HasPrev: true, First: 1, Last: 3},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			paging := New(nil).Limit(tt.limit).Offset(tt.offset).(*pagingQuery)
+			if err := paging.validateQuery(false); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if skip := paging.getSkip(); skip != tt.offset {
+				t.Errorf("expected skip to be %d, got %d", tt.offset, skip)
+			}
+			paginationInfo := paging.paginator(tt.count)
+			paginationInfo.Count = tt.returned
+			if data := paginationInfo.PaginationData(); *data != tt.expected {
+				t.Errorf("expected pagination data to be %+v, got %+v", tt.expected, *data)
+			}
+		})
+	}
+}
+
+func TestClampPage(t *testing.T) {
+	tc := []struct {
+		name     string
+		count    int64
+		page     int64
+		policy   OutOfRangePolicy
+		expected int64
+		err      bool
+	}{
+		{name: "in range empty", count: 25, page: 2, policy: OutOfRangeEmpty, expected: 2},
+		{name: "in range clamp", count: 25, page: 3, policy: OutOfRangeClamp, expected: 3},
+		{name: "in range error", count: 25, page: 3, policy: OutOfRangeError, expected: 3},
+		{name: "first page of empty result", count: 0, page: 1, policy: OutOfRangeError, expected: 1},
+		{name: "out of range empty", count: 25, page: 5, policy: OutOfRangeEmpty, expected: 5},
+		{name: "out of range clamp", count: 25, page: 5, policy: OutOfRangeClamp, expected: 3},
+		{name: "out of range clamp empty result", count: 0, page: 5, policy: OutOfRangeClamp, expected: 1},
+		{name: "out of range error", count: 25, page: 5, policy: OutOfRangeError, err: true},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			paginator, err := clampPage(newPaginator(tt.count, tt.page, 10), tt.policy)
+			if tt.err {
+				if !errors.Is(err, ErrPageOutOfRange) {
+					t.Fatalf("expected ErrPageOutOfRange, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if paginator.Page != tt.expected {
+				t.Errorf("expected page to be %d, got %d", tt.expected, paginator.Page)
+			}
+			if paginator.Offset != getSkip(tt.expected, 10) {
+				t.Errorf("expected offset to be %d, got %d", getSkip(tt.expected, 10), paginator.Offset)
+			}
+			data := paginator.PaginationData()
+			if data.Next != 0 && data.Next > data.TotalPage {
+				t.Errorf("next page %d exceeds total page %d", data.Next, data.TotalPage)
+			}
+		})
+	}
+}
+
+func TestPageWindow(t *testing.T) {
+	const E = Ellipsis
+	tc := []struct {
+		name      string
+		page      int64
+		totalPage int64
+		size      int64
+		edges     int64
+		expected  []int64
+	}{
+		{name: "no pages", page: 1, totalPage: 0, size: 5, edges: 1, expected: nil},
+		{name: "single page", page: 1, totalPage: 1, size: 5, edges: 1, expected: []int64{1}},
+		{name: "all pages fit", page: 3, totalPage: 6, size: 5, edges: 1, expected: []int64{1, 2, 3, 4, 5, 6}},
+		{name: "middle", page: 6, totalPage: 42, size: 5, edges: 1, expected: []int64{1, E, 4, 5, 6, 7, 8, E, 42}},
+		{name: "start", page: 1, totalPage: 42, size: 5, edges: 1, expected: []int64{1, 2, 3, 4, 5, E, 42}},
+		{name: "end", page: 42, totalPage: 42, size: 5, edges: 1, expected: []int64{1, E, 38, 39, 40, 41, 42}},
+		{name: "single gap filled", page: 4, totalPage: 42, size: 3, edges: 1, expected: []int64{1, 2, 3, 4, 5, E, 42}},
+		{name: "two edges", page: 20, totalPage: 42, size: 3, edges: 2, expected: []int64{1, 2, E, 19, 20, 21, E, 41, 42}},
+		{name: "no edges", page: 20, totalPage: 42, size: 3, edges: 0, expected: []int64{E, 19, 20, 21, E}},
+		{name: "no edges at end", page: 41, totalPage: 42, size: 3, edges: 0, expected: []int64{E, 40, 41, 42}},
+		{name: "even size", page: 20, totalPage: 42, size: 4, edges: 1, expected: []int64{1, E, 19, 20, 21, 22, E, 42}},
+		{name: "page beyond last page", page: 50, totalPage: 42, size: 3, edges: 1, expected: []int64{1, E, 40, 41, 42}},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			data := PaginationData{Page: tt.page, TotalPage: tt.totalPage}
+			window := data.PageWindow(tt.size, tt.edges)
+			if fmt.Sprint(window) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected page window to be %v, got %v", tt.expected, window)
+			}
+		})
+	}
+}
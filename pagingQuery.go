@@ -6,6 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"log/slog"
+	"reflect"
+	"time"
 )
 
 // Error constants
@@ -15,22 +20,59 @@ const (
 	DecodeNotAvail         = "this feature is not available for aggregate query"
 	FilterInAggregateError = "you cannot use filter in aggregate query but you can pass multiple filter as param in aggregate function"
 	NilFilterError         = "filter query cannot be nil"
+	PageOutOfRangeError    = "page is out of range"
+	DecodeSliceError       = "decode target should be pointer to slice"
+	SnapshotTokenError     = "invalid snapshot token"
+	IteratorQueryError     = "query cannot be iterated, it should be created with New"
+	ExportFormatError      = "unknown export format"
+	ExportColumnsError     = "csv export needs columns or projection"
+	CursorTokenError       = "invalid cursor token"
+	MergeSourcesError      = "at least one collection should be provided to merge"
+	UnionCollectionError   = "union collection name cannot be empty"
+	ArrayPathError         = "array field path cannot be empty"
+	GroupKeyError          = "group key cannot be empty"
+	DistinctFieldError     = "distinct field cannot be empty"
+	GeoKeyError            = "geo key field cannot be empty"
+	FacetNameError         = "facet name should be unique field name other than data and total"
+	RankedQueryError       = "query ranked by Search or Near can only be paginated with Find or Aggregate"
 )
 
+// ErrPageOutOfRange is returned when a page beyond the last page is
+// requested with OutOfRangeError policy
+var ErrPageOutOfRange = errors.New(PageOutOfRangeError)
+
 // PagingQuery struct for holding mongo
 // connection, filter needed to apply
 // filter data with page, limit, sort key
 // and sort value
 type pagingQuery struct {
-	Collection  *mongo.Collection
-	SortFields  bson.D
-	Ctx         context.Context
-	Decoder     interface{}
-	Project     interface{}
-	FilterQuery interface{}
-	LimitCount  int64
-	PageCount   int64
-	Collation   *options.Collation
+	Collection     *mongo.Collection
+	SortFields     bson.D
+	Ctx            context.Context
+	Decoder        interface{}
+	Project        interface{}
+	FilterQuery    interface{}
+	LimitCount     int64
+	PageCount      int64
+	OffsetCount    int64
+	ByOffset       bool
+	Collation      *options.Collation
+	OutOfRange     OutOfRangePolicy
+	Interceptors   []Interceptor
+	ResultCache    Cache
+	Prefetcher     *Prefetcher
+	Session        mongo.Session
+	SnapshotReads  bool
+	SnapshotToken  string
+	ReadPreference *readpref.ReadPref
+	ReadConcern    *readconcern.ReadConcern
+	Unions         []UnionWith
+	Grouping       *GroupBy
+	Facets         []Facet
+	Ranking        *ranking
+	AfterCursor    string
+	SeekValues     []interface{}
+	EmptyPage      bool
 }
 
 // AutoGenerated is to bind Aggregate query result data
@@ -48,16 +90,34 @@ type PagingQuery interface {
 	Find() (paginatedData *PaginatedData, err error)
 
 	Aggregate(criteria ...interface{}) (paginatedData *PaginatedData, err error)
+	FindArray(path string, elementFilter interface{}) (paginatedData *PaginatedData, err error)
+	AggregateGroups(group GroupBy, criteria ...interface{}) (paginatedData *PaginatedData, err error)
+	Distinct(field, prefix string) (paginatedData *PaginatedData, err error)
 
 	// Select used to enable fields which should be retrieved.
 	Select(selector interface{}) PagingQuery
 	Filter(selector interface{}) PagingQuery
 	Limit(limit int64) PagingQuery
 	Page(page int64) PagingQuery
+	Offset(offset int64) PagingQuery
 	Sort(sortField string, sortValue interface{}) PagingQuery
 	Decode(decode interface{}) PagingQuery
 	Context(ctx context.Context) PagingQuery
 	SetCollation(ctx *options.Collation) PagingQuery
+	SetOutOfRangePolicy(policy OutOfRangePolicy) PagingQuery
+	SetCache(cache Cache) PagingQuery
+	Prefetch(prefetcher *Prefetcher) PagingQuery
+	SetSession(session mongo.Session) PagingQuery
+	Snapshot(token string) PagingQuery
+	SetReadPreference(readPreference *readpref.ReadPref) PagingQuery
+	SetReadConcern(readConcern *readconcern.ReadConcern) PagingQuery
+	Union(collection string, pipeline ...bson.M) PagingQuery
+	AddFacets(facets ...Facet) PagingQuery
+	Search(term, language string) PagingQuery
+	Near(near GeoNear) PagingQuery
+	After(cursor string) PagingQuery
+	Intercept(interceptors ...Interceptor) PagingQuery
+	SlowLog(logger *slog.Logger, threshold time.Duration, redactFields ...string) PagingQuery
 }
 
 // New is to construct PagingQuery object with mongo.Database and collection name
@@ -73,6 +133,12 @@ func (paging *pagingQuery) SetCollation(collation *options.Collation) PagingQuer
 	return paging
 }
 
+// SetOutOfRangePolicy is function to set how pages beyond the last page are served
+func (paging *pagingQuery) SetOutOfRangePolicy(policy OutOfRangePolicy) PagingQuery {
+	paging.OutOfRange = policy
+	return paging
+}
+
 // Decode is function to decode result data
 func (paging *pagingQuery) Decode(decode interface{}) PagingQuery {
 	paging.Decoder = decode
@@ -113,6 +179,19 @@ func (paging *pagingQuery) Page(page int64) PagingQuery {
 	} else {
 		paging.PageCount = page
 	}
+	paging.ByOffset = false
+	return paging
+}
+
+// Offset is to specify how many documents to skip instead of page number,
+// page number is then derived from offset and limit
+func (paging *pagingQuery) Offset(offset int64) PagingQuery {
+	if offset < 0 {
+		paging.OffsetCount = 0
+	} else {
+		paging.OffsetCount = offset
+	}
+	paging.ByOffset = true
 	return paging
 }
 
@@ -127,7 +206,7 @@ func (paging *pagingQuery) Sort(sortField string, sortValue interface{}) PagingQ
 
 // validateQuery query is to check if user has added certain required params or not
 func (paging *pagingQuery) validateQuery(isNormal bool) error {
-	if paging.LimitCount <= 0 || paging.PageCount <= 0 {
+	if paging.LimitCount <= 0 || (paging.PageCount <= 0 && !paging.ByOffset) {
 		return errors.New(PageLimitError)
 	}
 	if isNormal && paging.Decoder == nil {
@@ -151,13 +230,39 @@ func (paging *pagingQuery) getContext() context.Context {
 // it returns PaginatedData struct and  error if any error
 // occurs during document query
 func (paging *pagingQuery) Aggregate(filters ...interface{}) (paginatedData *PaginatedData, err error) {
+	query := paging
 	// checking if user added required params
-	if err := paging.validateQuery(false); err != nil {
+	err = paging.validate(AggregateOperation, func() error {
+		if err := paging.validateQuery(false); err != nil {
+			return err
+		}
+		if paging.FilterQuery != nil {
+			return errors.New(FilterInAggregateError)
+		}
+		if paging.Grouping != nil && paging.Grouping.Key == "" {
+			return errors.New(GroupKeyError)
+		}
+		// documents of unions and groups would not pass through
+		// stages of ranking
+		if paging.Grouping != nil || len(paging.Unions) > 0 {
+			if err := paging.unranked(); err != nil {
+				return err
+			}
+		}
+		for _, union := range paging.Unions {
+			if union.Collection == "" {
+				return errors.New(UnionCollectionError)
+			}
+		}
+		if err := validateFacets(paging.Facets); err != nil {
+			return err
+		}
+		query, err = paging.afterCursor()
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	if paging.FilterQuery != nil {
-		return nil, errors.New(FilterInAggregateError)
-	}
 
 	var aggregationFilter []bson.M
 	// combining user sent queries
@@ -171,75 +276,215 @@ LOOP:
 			aggregationFilter = append(aggregationFilter, filter.(bson.M))
 		}
 	}
+	if len(paging.Unions) > 0 && paging.Grouping == nil {
+		// pipeline passed by caller is left untouched, groups
+		// already hold documents of unions
+		aggregationFilter = aggregationFilter[:len(aggregationFilter):len(aggregationFilter)]
+		aggregationFilter = append(aggregationFilter, paging.unionStages(aggregationFilter)...)
+	}
 
-	skip := getSkip(paging.PageCount, paging.LimitCount)
-	var facetData []bson.M
-	if len(paging.SortFields) > 0 {
-		facetData = append(facetData, bson.M{"$sort": paging.SortFields})
+	return query.paginate(AggregateOperation, aggregationFilter, func(ctx context.Context) (*PaginatedData, error) {
+		return query.aggregatePage(ctx, aggregationFilter)
+	})
+}
+
+// aggregatePage queries page of aggregation pipeline result
+func (paging *pagingQuery) aggregatePage(ctx context.Context, aggregationFilter []bson.M) (*PaginatedData, error) {
+	skip := paging.getSkip()
+	data, aggCount, facets, err := paging.aggregate(ctx, aggregationFilter, skip, paging.SeekValues)
+	if err != nil {
+		return nil, err
+	}
+	paginationInfoChan := make(chan *Paginator, 1)
+	Paging(paging, paginationInfoChan, true, aggCount)
+	paginationInfo, err := clampPage(<-paginationInfoChan, paging.OutOfRange)
+	if err != nil {
+		return nil, err
+	}
+	if paginationInfo.Offset != skip {
+		// page was clamped so last page has to be queried again
+		data, aggCount, facets, err = paging.aggregate(ctx, aggregationFilter, paginationInfo.Offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		paginationInfo = newPaginator(aggCount, paginationInfo.Page, paginationInfo.Limit)
+	}
+	paginationInfo.Count = int64(len(data))
+	result := PaginatedData{
+		Pagination: *paginationInfo.PaginationData(),
+		Data:       data,
+		Facets:     facets,
+	}
+	if paging.Ranking != nil && result.Pagination.HasNext {
+		result.Pagination.Cursor = paging.rankCursor(data, paginationInfo.Offset)
+	}
+	return &result, nil
+}
+
+// aggregate runs pipeline followed by $facet stage which fetches one page
+// of data starting at skip, or following seek values when they are set,
+// along with total document count and facets
+func (paging *pagingQuery) aggregate(ctx context.Context, pipeline []bson.M, skip int64, seek []interface{}) (data []bson.Raw, count int64, facets map[string][]FacetBucket, err error) {
+	if paging.Ranking != nil {
+		// total and facets count every ranked document, seek values
+		// only narrow down data
+		pipeline = paging.rankedPipeline(nil, pipeline, nil)
+	}
+	facetData := paging.pageStages(paging.sortFields(), skip)
+	if seek != nil {
+		facetData = append([]bson.M{{"$match": seekFilter(paging.sortFields(), seek)}}, paging.pageStages(paging.sortFields(), 0)...)
 	}
-	facetData = append(facetData, bson.M{"$skip": skip})
-	facetData = append(facetData, bson.M{"$limit": paging.LimitCount})
 
-	//if paging.SortField != "" {
-	//	facetData = append(facetData, bson.M{"$sort": bson.M{paging.SortField: paging.SortValue}})
-	//}
 	// making facet aggregation pipeline for result and total document count
-	facet := bson.M{"$facet": bson.M{
+	stages := bson.M{
 		"data":  facetData,
 		"total": []bson.M{{"$count": "count"}},
-	},
 	}
-	aggregationFilter = append(aggregationFilter, facet)
+	if paging.EmptyPage {
+		delete(stages, "data")
+	}
+	for _, f := range paging.Facets {
+		stages[f.Name] = f.pipeline()
+	}
+	facet := bson.M{"$facet": stages}
+	aggregationFilter := append(pipeline[:len(pipeline):len(pipeline)], facet)
 	diskUse := true
 	opt := &options.AggregateOptions{
 		AllowDiskUse: &diskUse,
 	}
-	ctx := paging.getContext()
-	cursor, err := paging.Collection.Aggregate(ctx, aggregationFilter, opt)
+	collection, err := paging.collection()
 	if err != nil {
-		return nil, err
+		return nil, 0, nil, err
+	}
+	op := Operation{
+		Kind:             AggregateOperation,
+		Ctx:              ctx,
+		Collection:       collection,
+		Pipeline:         aggregationFilter,
+		AggregateOptions: opt,
+		cacheable:        true,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, 0, nil, err
+	}
+	raws, err := paging.documents(&op)
+	if err != nil {
+		return nil, 0, nil, err
 	}
-	defer cursor.Close(ctx)
 	var docs []AutoGenerated
-	for cursor.Next(ctx) {
-		var document *AutoGenerated
-		if err := cursor.Decode(&document); err == nil {
-			docs = append(docs, *document)
+	for _, raw := range raws {
+		var document AutoGenerated
+		if err := bson.Unmarshal(raw, &document); err == nil {
+			docs = append(docs, document)
+			if facets == nil {
+				facets = paging.decodeFacets(raw)
+			}
 		}
 	}
 
-	var data []bson.Raw
-	var aggCount int64
-
-	if len(docs) > 0 && len(docs[0].Data) > 0 {
-		aggCount = docs[0].Total[0].Count
+	if len(docs) > 0 {
+		// total is counted independently of data so that pages
+		// beyond the last page still report total
+		if len(docs[0].Total) > 0 {
+			count = docs[0].Total[0].Count
+		}
 		data = docs[0].Data
 	}
-	paginationInfoChan := make(chan *Paginator, 1)
-	Paging(paging, paginationInfoChan, true, aggCount)
-	paginationInfo := <-paginationInfoChan
-	result := PaginatedData{
-		Pagination: *paginationInfo.PaginationData(),
-		Data:       data,
-	}
-	return &result, nil
+	return data, count, facets, nil
 }
 
 // Find returns two value pagination data with document queried from mongodb and
 // error if any error occurs during document query
 func (paging *pagingQuery) Find() (paginatedData *PaginatedData, err error) {
-	if err := paging.validateQuery(true); err != nil {
+	query := paging
+	err = paging.validate(FindOperation, func() error {
+		if err := paging.validateQuery(true); err != nil {
+			return err
+		}
+		if paging.FilterQuery == nil {
+			return errors.New(NilFilterError)
+		}
+		query, err = paging.afterCursor()
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	if paging.FilterQuery == nil {
-		return nil, errors.New(NilFilterError)
+	paginatedData, err = query.paginate(FindOperation, query.FilterQuery, query.find)
+	if err != nil {
+		return nil, err
+	}
+	query.prefetchNext(paginatedData)
+	return paginatedData, nil
+}
+
+// find queries page of documents and decodes them into Decoder
+func (paging *pagingQuery) find(ctx context.Context) (*PaginatedData, error) {
+	if paging.Ranking != nil {
+		return paging.findRanked(ctx)
 	}
 	// get Pagination Info
-	paginationInfoChan := make(chan *Paginator, 1)
-	Paging(paging, paginationInfoChan, false, 0)
+	count, err := paging.count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paginationInfo, err := clampPage(paging.paginator(count), paging.OutOfRange)
+	if err != nil {
+		return nil, err
+	}
 
-	// set options for sorting and skipping
-	skip := getSkip(paging.PageCount, paging.LimitCount)
+	skip := paginationInfo.Offset
+	docs := paging.Decoder
+	if paging.EmptyPage {
+		// only total is queried, as for $top=0 of OData
+		if err := decodeRaw(nil, docs); err != nil {
+			return nil, err
+		}
+		result := PaginatedData{
+			Pagination: *paginationInfo.PaginationData(),
+		}
+		return &result, nil
+	}
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, err
+	}
+	op := Operation{
+		Kind:        FindOperation,
+		Ctx:         ctx,
+		Collection:  collection,
+		Filter:      paging.FilterQuery,
+		FindOptions: paging.findOptions(skip),
+		cacheable:   true,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, err
+	}
+	if paging.cacheEnabled() {
+		// raw documents are kept to be cached before decoding
+		data, err := paging.documents(&op)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeRaw(data, docs); err != nil {
+			return nil, err
+		}
+	} else {
+		cursor := op.Cursor
+		defer cursor.Close(ctx)
+		if err := cursor.All(ctx, docs); err != nil {
+			return nil, err
+		}
+	}
+	paginationInfo.Count = decodedLen(docs)
+	result := PaginatedData{
+		Pagination: *paginationInfo.PaginationData(),
+	}
+	return &result, nil
+}
+
+// findOptions returns options for sorting and skipping of page at skip
+func (paging *pagingQuery) findOptions(skip int64) *options.FindOptions {
 	opt := &options.FindOptions{
 		Skip:  &skip,
 		Limit: &paging.LimitCount,
@@ -253,30 +498,63 @@ func (paging *pagingQuery) Find() (paginatedData *PaginatedData, err error) {
 	if paging.Collation != nil {
 		opt.SetCollation(paging.Collation)
 	}
+	return opt
+}
 
-	ctx := paging.getContext()
-	cursor, err := paging.Collection.Find(ctx, paging.FilterQuery, opt)
-	if err != nil {
-		return nil, err
+// PaginatedData struct holds data and
+// pagination detail
+type PaginatedData struct {
+	Data       []bson.Raw               `json:"data"`
+	Pagination PaginationData           `json:"pagination"`
+	Facets     map[string][]FacetBucket `json:"facets,omitempty"`
+}
+
+// decodeRaw decodes raw documents into pointer to slice
+func decodeRaw(data []bson.Raw, docs interface{}) error {
+	v := reflect.ValueOf(docs)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New(DecodeSliceError)
 	}
-	defer cursor.Close(ctx)
-	docs := paging.Decoder
-	err = cursor.All(ctx, docs)
-	if err != nil {
-		return nil, err
+	slice := reflect.MakeSlice(v.Elem().Type(), len(data), len(data))
+	for i, raw := range data {
+		if err := bson.Unmarshal(raw, slice.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
 	}
-	paginationInfo := <-paginationInfoChan
-	result := PaginatedData{
-		Pagination: *paginationInfo.PaginationData(),
+	v.Elem().Set(slice)
+	return nil
+}
+
+// decodedLen returns number of documents decoded into
+// pointer to slice or array
+func decodedLen(docs interface{}) int64 {
+	v := reflect.ValueOf(docs)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
 	}
-	return &result, nil
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return int64(v.Len())
+	}
+	return 0
 }
 
-// PaginatedData struct holds data and
-// pagination detail
-type PaginatedData struct {
-	Data       []bson.Raw     `json:"data"`
-	Pagination PaginationData `json:"pagination"`
+// getSkip return skip value for query either from
+// offset or from page and limit
+func (paging *pagingQuery) getSkip() int64 {
+	if paging.ByOffset {
+		return paging.OffsetCount
+	}
+	return getSkip(paging.PageCount, paging.LimitCount)
+}
+
+// getPage return requested page, for offset based query
+// it is the page holding the first requested document
+func (paging *pagingQuery) getPage() int64 {
+	if paging.ByOffset && paging.LimitCount > 0 {
+		return paging.OffsetCount/paging.LimitCount + 1
+	}
+	return paging.PageCount
 }
 
 // getSkip return calculated skip value for query
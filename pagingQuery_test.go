@@ -293,3 +293,18 @@ func TestGetSkip(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodedLen(t *testing.T) {
+	var todos []TodoTest
+	if n := decodedLen(&todos); n != 0 {
+		t.Fatalf("expected decoded length to be 0, got %d", n)
+	}
+	todos = make([]TodoTest, 3)
+	if n := decodedLen(&todos); n != 3 {
+		t.Fatalf("expected decoded length to be 3, got %d", n)
+	}
+	var todo TodoTest
+	if n := decodedLen(&todo); n != 0 {
+		t.Fatalf("expected decoded length to be 0, got %d", n)
+	}
+}
@@ -0,0 +1,335 @@
+package mongopagination
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"sync"
+)
+
+// defaultPartitions is number of ranges collection is split into
+// when PartitionOptions.Partitions is not set
+const defaultPartitions = 4
+
+// PartitionOptions configures Partitioned
+type PartitionOptions struct {
+	// Partitions is number of ranges documents are split into
+	Partitions int
+	// Workers is number of ranges read at once, defaults to Partitions
+	Workers int
+	// Key is field ranges are split on, defaults to _id. Documents with
+	// key of other type than split points are read with the first range
+	Key string
+	// SampleSize takes split points from $sample of that many documents
+	// instead of $bucketAuto when set
+	SampleSize int64
+	// Ordered delivers ranges one after another in key order, otherwise
+	// documents are delivered as soon as they are read
+	Ordered bool
+}
+
+// PartitionItem is document read by Partitioned along with index of its
+// range. Err is set on the last item when reading fails, Partition is -1
+// when the error is not tied to a range
+type PartitionItem[T any] struct {
+	Doc       T
+	Partition int
+	Err       error
+}
+
+// Partitioned reads every document matching query through the returned
+// channel. Documents are split into disjoint ranges of key which are read
+// concurrently by a pool of workers, each range is walked in batches of
+// query limit the same way as Pages. Sort and page of query are ignored.
+// Channel is closed once all documents are read, caller that stops early
+// has to cancel query context
+func Partitioned[T any](query PagingQuery, opts PartitionOptions) <-chan PartitionItem[T] {
+	out := make(chan PartitionItem[T])
+	go func() {
+		defer close(out)
+		paging, ok := query.(*pagingQuery)
+		if !ok {
+			out <- PartitionItem[T]{Partition: -1, Err: errors.New(IteratorQueryError)}
+			return
+		}
+		ctx := paging.getContext()
+		send := func(item PartitionItem[T]) bool {
+			select {
+			case out <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		err := paging.partitioned(opts, func(partition int, batch []bson.Raw) bool {
+			for _, raw := range batch {
+				item := PartitionItem[T]{Partition: partition}
+				if err := bson.Unmarshal(raw, &item.Doc); err != nil {
+					send(PartitionItem[T]{Partition: partition, Err: err})
+					return false
+				}
+				if !send(item) {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			send(PartitionItem[T]{Partition: -1, Err: err})
+		}
+	}()
+	return out
+}
+
+// partitionBatch is batch of documents read from range of partition
+type partitionBatch struct {
+	partition int
+	docs      []bson.Raw
+}
+
+// partitioned reads ranges of query concurrently and passes their batches
+// to deliver until all are read or deliver returns false
+func (paging *pagingQuery) partitioned(opts PartitionOptions, deliver func(partition int, batch []bson.Raw) bool) error {
+	if err := paging.unranked(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(paging.getContext())
+	defer cancel()
+	if opts.Partitions <= 0 {
+		opts.Partitions = defaultPartitions
+	}
+	if opts.Key == "" {
+		opts.Key = "_id"
+	}
+	filter := paging.FilterQuery
+	if filter == nil {
+		filter = bson.M{}
+	}
+	points, err := paging.splitPoints(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	filters := partitionFilters(filter, opts.Key, partitionBounds(points))
+	workers := opts.Workers
+	if workers <= 0 || workers > len(filters) {
+		workers = len(filters)
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	// ordered delivery reads channel of every range in turn, unordered
+	// delivery reads channel shared by all ranges
+	channels := make([]chan partitionBatch, len(filters))
+	shared := make(chan partitionBatch)
+	for i := range channels {
+		channels[i] = shared
+		if opts.Ordered {
+			channels[i] = make(chan partitionBatch, 1)
+		}
+	}
+	jobs := make(chan int, len(filters))
+	for i := range filters {
+		jobs <- i
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				part := *paging
+				part.Ctx = ctx
+				part.FilterQuery = filters[i]
+				part.SortFields = bson.D{{Key: opts.Key, Value: 1}}
+				part.PageCount, part.OffsetCount, part.ByOffset = 0, 0, false
+				err := part.scan(scanPosition{}, func(batch []bson.Raw, _ scanPosition) bool {
+					select {
+					case channels[i] <- partitionBatch{partition: i, docs: batch}:
+						return true
+					case <-ctx.Done():
+						return false
+					}
+				})
+				if err != nil {
+					fail(err)
+				}
+				if opts.Ordered {
+					close(channels[i])
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	if !opts.Ordered {
+		go func() {
+			wg.Wait()
+			close(shared)
+		}()
+	}
+
+	stopped := false
+	receive := func(channel chan partitionBatch) bool {
+		for ctx.Err() == nil {
+			select {
+			case batch, ok := <-channel:
+				if !ok {
+					return true
+				}
+				if !deliver(batch.partition, batch.docs) {
+					stopped = true
+					return false
+				}
+			case <-ctx.Done():
+			}
+		}
+		return false
+	}
+	if opts.Ordered {
+		for _, channel := range channels {
+			if !receive(channel) {
+				break
+			}
+		}
+	} else {
+		receive(shared)
+	}
+	cancel()
+	wg.Wait()
+	if stopped {
+		return nil
+	}
+	if firstErr == nil {
+		// workers stop without error once parent context is done
+		return paging.getContext().Err()
+	}
+	return firstErr
+}
+
+// splitPoints returns sorted values of key splitting documents matching
+// filter into ranges of about equal size
+func (paging *pagingQuery) splitPoints(ctx context.Context, filter interface{}, opts PartitionOptions) ([]bson.RawValue, error) {
+	if opts.Partitions < 2 {
+		return nil, nil
+	}
+	// lower bound of every bucket but the first one is a split point
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$bucketAuto": bson.M{"groupBy": "$" + opts.Key, "buckets": opts.Partitions}},
+	}
+	path := []string{"_id", "min"}
+	if opts.SampleSize > 0 {
+		// split points are quantiles of sorted sample
+		pipeline = []bson.M{
+			{"$match": filter},
+			{"$sample": bson.M{"size": opts.SampleSize}},
+			{"$project": bson.M{opts.Key: 1}},
+			{"$sort": bson.M{opts.Key: 1}},
+		}
+		path = strings.Split(opts.Key, ".")
+	}
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, err
+	}
+	opt := options.Aggregate()
+	if paging.Collation != nil {
+		opt.SetCollation(paging.Collation)
+	}
+	op := Operation{
+		Kind:             AggregateOperation,
+		Ctx:              ctx,
+		Collection:       collection,
+		Pipeline:         pipeline,
+		AggregateOptions: opt,
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, err
+	}
+	cursor := op.Cursor
+	defer cursor.Close(ctx)
+	var values []bson.RawValue
+	for cursor.Next(ctx) {
+		value, err := cursor.Current.LookupErr(path...)
+		if err != nil {
+			continue
+		}
+		// value is copied as cursor reuses its buffer
+		value.Value = append([]byte{}, value.Value...)
+		values = append(values, value)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if opts.SampleSize > 0 {
+		return quantiles(values, opts.Partitions), nil
+	}
+	if len(values) > 0 {
+		values = values[1:]
+	}
+	return values, nil
+}
+
+// quantiles returns values splitting sorted values into n parts
+func quantiles(values []bson.RawValue, n int) []bson.RawValue {
+	if len(values) == 0 {
+		return nil
+	}
+	points := make([]bson.RawValue, 0, n-1)
+	for i := 1; i < n; i++ {
+		points = append(points, values[len(values)*i/n])
+	}
+	return points
+}
+
+// partitionBounds returns distinct split points of the type of the first
+// non null one, as ranges of values of different types do not overlap
+func partitionBounds(points []bson.RawValue) []interface{} {
+	var bounds []interface{}
+	var last bson.RawValue
+	for _, point := range points {
+		if point.Type == bson.TypeNull || point.Type == bson.TypeUndefined {
+			continue
+		}
+		if last.Type != 0 && (point.Type != last.Type || point.Equal(last)) {
+			continue
+		}
+		var value interface{}
+		if err := point.Unmarshal(&value); err != nil {
+			continue
+		}
+		bounds = append(bounds, value)
+		last = point
+	}
+	return bounds
+}
+
+// partitionFilters returns filter of every range between bounds. The first
+// range takes every document not above the first bound so that documents
+// missing key or holding key of other type are read as well, scan seeks
+// past values of every type so that such range can take many batches
+func partitionFilters(filter interface{}, key string, bounds []interface{}) []interface{} {
+	if len(bounds) == 0 {
+		return []interface{}{filter}
+	}
+	filters := make([]interface{}, 0, len(bounds)+1)
+	filters = append(filters, bson.M{"$and": []interface{}{filter, bson.M{key: bson.M{"$not": bson.M{"$gte": bounds[0]}}}}})
+	for i := 1; i < len(bounds); i++ {
+		filters = append(filters, bson.M{"$and": []interface{}{filter, bson.M{key: bson.M{"$gte": bounds[i-1], "$lt": bounds[i]}}}})
+	}
+	filters = append(filters, bson.M{"$and": []interface{}{filter, bson.M{key: bson.M{"$gte": bounds[len(bounds)-1]}}}})
+	return filters
+}
@@ -0,0 +1,138 @@
+package mongopagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func rawInt(v int32) bson.RawValue {
+	return bson.RawValue{Type: bsontype.Int32, Value: bsoncore.AppendInt32(nil, v)}
+}
+
+func TestPartitionBounds(t *testing.T) {
+	null := bson.RawValue{Type: bsontype.Null}
+	text := bson.RawValue{Type: bsontype.String, Value: bsoncore.AppendString(nil, "a")}
+	bounds := partitionBounds([]bson.RawValue{null, rawInt(10), rawInt(10), text, rawInt(20)})
+	if !reflect.DeepEqual(bounds, []interface{}{int32(10), int32(20)}) {
+		t.Errorf("expected distinct bounds of single type, got %v", bounds)
+	}
+
+	values := []bson.RawValue{rawInt(1), rawInt(2), rawInt(3), rawInt(4), rawInt(5), rawInt(6)}
+	points := quantiles(values, 3)
+	if len(points) != 2 || points[0].Int32() != 3 || points[1].Int32() != 5 {
+		t.Errorf("expected split points 3 and 5, got %v", points)
+	}
+}
+
+func TestPartitionFilters(t *testing.T) {
+	filter := bson.M{"status": "active"}
+	filters := partitionFilters(filter, "_id", []interface{}{10, 20})
+	expected := []interface{}{
+		bson.M{"$and": []interface{}{filter, bson.M{"_id": bson.M{"$not": bson.M{"$gte": 10}}}}},
+		bson.M{"$and": []interface{}{filter, bson.M{"_id": bson.M{"$gte": 10, "$lt": 20}}}},
+		bson.M{"$and": []interface{}{filter, bson.M{"_id": bson.M{"$gte": 20}}}},
+	}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("expected %v, got %v", expected, filters)
+	}
+	if filters := partitionFilters(filter, "_id", nil); !reflect.DeepEqual(filters, []interface{}{filter}) {
+		t.Errorf("expected single range of filter, got %v", filters)
+	}
+}
+
+func TestPartitionedError(t *testing.T) {
+	vetoErr := errors.New("vetoed")
+	var pipeline []bson.M
+	query := New(nil).Filter(bson.M{"status": "active"}).Intercept(func(op *Operation, next Handler) error {
+		pipeline = op.Pipeline.([]bson.M)
+		return vetoErr
+	})
+	var items []PartitionItem[TodoTest]
+	for item := range Partitioned[TodoTest](query, PartitionOptions{Partitions: 3, Key: "createdAt"}) {
+		items = append(items, item)
+	}
+	if len(items) != 1 || !errors.Is(items[0].Err, vetoErr) || items[0].Partition != -1 {
+		t.Fatalf("expected single vetoed error, got %+v", items)
+	}
+	expected := bson.M{"groupBy": "$createdAt", "buckets": 3}
+	if len(pipeline) != 2 || !reflect.DeepEqual(pipeline[1]["$bucketAuto"], expected) {
+		t.Errorf("expected $bucketAuto split points, got %v", pipeline)
+	}
+}
+
+func TestPartitionedWorkerError(t *testing.T) {
+	vetoErr := errors.New("vetoed")
+	for _, ordered := range []bool{true, false} {
+		var filter interface{}
+		query := New(nil).Limit(5).Page(3).Intercept(func(op *Operation, next Handler) error {
+			filter = op.Filter
+			if *op.FindOptions.Skip != 0 {
+				t.Errorf("expected range to be read from its start, got skip %d", *op.FindOptions.Skip)
+			}
+			return vetoErr
+		})
+		var items []PartitionItem[TodoTest]
+		for item := range Partitioned[TodoTest](query, PartitionOptions{Partitions: 1, Ordered: ordered}) {
+			items = append(items, item)
+		}
+		if len(items) != 1 || !errors.Is(items[0].Err, vetoErr) {
+			t.Fatalf("expected single vetoed error, got %+v", items)
+		}
+		if !reflect.DeepEqual(filter, bson.M{}) {
+			t.Errorf("expected whole collection in single range, got %v", filter)
+		}
+	}
+}
+
+func TestPartitioned_MixedTypes(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("PartitionTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	var docs []interface{}
+	for i := 0; i < 12; i++ {
+		docs = append(docs, bson.M{"title": fmt.Sprintf("number-%d", i), "rank": i})
+	}
+	// documents of other types than split points fall into the first range
+	docs = append(docs,
+		bson.M{"title": "null", "rank": nil},
+		bson.M{"title": "missing"},
+		bson.M{"title": "string-a", "rank": "a"},
+		bson.M{"title": "string-b", "rank": "b"},
+		bson.M{"title": "bool", "rank": true},
+	)
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+
+	for _, opts := range []PartitionOptions{
+		{Partitions: 3, Key: "rank", Ordered: true},
+		{Partitions: 3, Key: "rank", SampleSize: 17},
+	} {
+		// batches of 2 make the first range cross types
+		seen := map[string]int{}
+		for item := range Partitioned[TodoTest](New(collection).Context(ctx).Limit(2).Filter(bson.M{}), opts) {
+			if item.Err != nil {
+				t.Fatalf("expected no error, got %v", item.Err)
+			}
+			seen[item.Doc.Title]++
+		}
+		if len(seen) != len(docs) {
+			t.Errorf("expected %d documents of options %+v, got %v", len(docs), opts, seen)
+		}
+		for title, n := range seen {
+			if n != 1 {
+				t.Errorf("expected %s to be read once, got %d", title, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package mongopagination
+
+import (
+	"sync"
+)
+
+// Prefetcher fetches the page following the one returned by Find into cache
+// of query in background. It runs at most concurrency fetches at once,
+// pages which would exceed the limit are not prefetched
+type Prefetcher struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewPrefetcher returns Prefetcher running up to concurrency fetches at once
+func NewPrefetcher(concurrency int) *Prefetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Prefetcher{slots: make(chan struct{}, concurrency)}
+}
+
+// Wait blocks until running fetches are done
+func (prefetcher *Prefetcher) Wait() {
+	prefetcher.wg.Wait()
+}
+
+// Prefetch is function to fetch next page into cache once Find returns a
+// page. It has no effect unless cache is set with SetCache. Fetch runs with
+// query context so it is cancelled once the context is done
+func (paging *pagingQuery) Prefetch(prefetcher *Prefetcher) PagingQuery {
+	paging.Prefetcher = prefetcher
+	return paging
+}
+
+// prefetchNext starts fetch of page following paginated data into cache
+func (paging *pagingQuery) prefetchNext(paginatedData *PaginatedData) {
+	if paging.Prefetcher == nil || !paging.cacheEnabled() || paginatedData == nil || paging.Ranking != nil ||
+		paging.LimitCount <= 0 || !paginatedData.Pagination.HasNext {
+		return
+	}
+	skip := paginatedData.Pagination.Offset + paging.LimitCount
+	select {
+	case paging.Prefetcher.slots <- struct{}{}:
+	default:
+		return
+	}
+	// query may be changed by caller once Find returns
+	next := *paging
+	next.Interceptors = append([]Interceptor{}, paging.Interceptors...)
+	paging.Prefetcher.wg.Add(1)
+	go func() {
+		defer func() {
+			<-next.Prefetcher.slots
+			next.Prefetcher.wg.Done()
+		}()
+		next.prefetch(skip)
+	}()
+}
+
+// prefetch fetches page at skip into cache unless it is cached already,
+// errors are dropped as the page is fetched again when requested
+func (paging *pagingQuery) prefetch(skip int64) {
+	ctx := paging.getContext()
+	if ctx.Err() != nil {
+		return
+	}
+	collection, err := paging.collection()
+	if err != nil {
+		return
+	}
+	op := Operation{
+		Kind:        FindOperation,
+		Ctx:         ctx,
+		Collection:  collection,
+		Filter:      paging.FilterQuery,
+		FindOptions: paging.findOptions(skip),
+		cacheable:   true,
+	}
+	if err := paging.execute(&op); err != nil {
+		return
+	}
+	// documents are cached once read, cached page is left as is
+	paging.documents(&op)
+}
@@ -0,0 +1,98 @@
+package mongopagination
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_Prefetch(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute, time.Minute)
+	prefetcher := NewPrefetcher(1)
+	filter := bson.M{"status": "active"}
+	raw, _ := bson.Marshal(TodoTest{Title: "todo-1", Status: "active"})
+
+	var mu sync.Mutex
+	var skips []int64
+	release := make(chan struct{})
+	intercept := func(op *Operation, next Handler) error {
+		if op.Kind != FindOperation {
+			return next(op)
+		}
+		key, _ := operationKey(op)
+		if _, ok := cache.Get(key); ok {
+			// cached pages do not reach mongo
+			return next(op)
+		}
+		mu.Lock()
+		skips = append(skips, *op.FindOptions.Skip)
+		mu.Unlock()
+		<-release
+		return errors.New("vetoed")
+	}
+	paging := New(nil).Limit(2).Page(1).Filter(filter).SetCache(cache).Prefetch(prefetcher).(*pagingQuery)
+	page := func(skip int64) Operation {
+		return Operation{Kind: FindOperation, Filter: filter, FindOptions: paging.findOptions(skip)}
+	}
+	cacheOperation(cache, Operation{Kind: CountOperation, Filter: filter, CountOptions: options.Count()}, int64(5))
+	cacheOperation(cache, page(0), []bson.Raw{raw, raw})
+	cacheOperation(cache, page(4), []bson.Raw{raw})
+
+	var todos []TodoTest
+	if _, err := paging.Decode(&todos).Intercept(intercept).Find(); err != nil {
+		t.Fatalf("expected cached page, got %v", err)
+	}
+	// concurrency limit is reached while first fetch is running
+	if _, err := paging.Page(1).Find(); err != nil {
+		t.Fatalf("expected cached page, got %v", err)
+	}
+	close(release)
+	prefetcher.Wait()
+	if len(skips) != 1 || skips[0] != 2 {
+		t.Errorf("expected single fetch of page 2, got skips %v", skips)
+	}
+
+	// page following cached one and last page are not fetched
+	skips = nil
+	cacheOperation(cache, page(2), []bson.Raw{raw, raw})
+	for _, page := range []int64{2, 3} {
+		if _, err := paging.Page(page).Find(); err != nil {
+			t.Fatalf("expected page %d, got %v", page, err)
+		}
+	}
+	prefetcher.Wait()
+	if len(skips) != 0 {
+		t.Errorf("expected no fetch, got skips %v", skips)
+	}
+}
+
+func TestPagingQuery_PrefetchCancelled(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute, time.Minute)
+	prefetcher := NewPrefetcher(2)
+	filter := bson.M{}
+	raw, _ := bson.Marshal(TodoTest{Title: "todo-1"})
+	ctx, cancel := context.WithCancel(context.Background())
+	paging := New(nil).Limit(1).Page(1).Filter(filter).Context(ctx).SetCache(cache).Prefetch(prefetcher).(*pagingQuery)
+	cacheOperation(cache, Operation{Kind: CountOperation, Filter: filter, CountOptions: options.Count()}, int64(2))
+	cacheOperation(cache, Operation{Kind: FindOperation, Filter: filter, FindOptions: paging.findOptions(0)}, []bson.Raw{raw})
+
+	var todos []TodoTest
+	fetched := false
+	cancel()
+	if _, err := paging.Decode(&todos).Intercept(func(op *Operation, next Handler) error {
+		if op.Kind == FindOperation && *op.FindOptions.Skip > 0 {
+			fetched = true
+		}
+		return next(op)
+	}).Find(); err != nil {
+		t.Fatalf("expected cached page, got %v", err)
+	}
+	prefetcher.Wait()
+	if fetched {
+		t.Errorf("expected no fetch once context is done")
+	}
+}
@@ -0,0 +1,209 @@
+// Package prompagination provides Prometheus metrics for paginated
+// queries: latency of count and data phases, requested page depth, skip
+// and result sizes, and validation failures by error type
+package prompagination
+
+import (
+	"errors"
+	paginate "github.com/gobeam/mongo-go-pagination"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Label names of exported metrics
+const (
+	CollectionLabel = "collection"
+	StrategyLabel   = "strategy"
+	ErrorLabel      = "error"
+)
+
+// Validation error types used as error label
+const (
+	PageLimitErrorType         = "page_limit"
+	DecodeEmptyErrorType       = "decode_empty"
+	DecodeNotAvailErrorType    = "decode_not_available"
+	FilterInAggregateErrorType = "filter_in_aggregate"
+	NilFilterErrorType         = "nil_filter"
+	PageOutOfRangeErrorType    = "page_out_of_range"
+	OtherErrorType             = "other"
+)
+
+// validationErrorTypes maps validation error messages to error types
+var validationErrorTypes = map[string]string{
+	paginate.PageLimitError:         PageLimitErrorType,
+	paginate.DecodeEmptyError:       DecodeEmptyErrorType,
+	paginate.DecodeNotAvail:         DecodeNotAvailErrorType,
+	paginate.FilterInAggregateError: FilterInAggregateErrorType,
+	paginate.NilFilterError:         NilFilterErrorType,
+}
+
+type config struct {
+	namespace      string
+	latencyBuckets []float64
+	pageBuckets    []float64
+	skipBuckets    []float64
+	resultBuckets  []float64
+	constantLabels prometheus.Labels
+}
+
+// Option configures metrics collector
+type Option func(*config)
+
+// WithNamespace sets namespace prefixed to metric names
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithLatencyBuckets sets buckets in seconds of latency histograms
+func WithLatencyBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.latencyBuckets = buckets
+	}
+}
+
+// WithDepthBuckets sets buckets of requested page and skip size histograms
+func WithDepthBuckets(page, skip []float64) Option {
+	return func(c *config) {
+		c.pageBuckets = page
+		c.skipBuckets = skip
+	}
+}
+
+// WithResultBuckets sets buckets of result size histogram
+func WithResultBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.resultBuckets = buckets
+	}
+}
+
+// WithConstLabels sets labels added to every metric
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constantLabels = labels
+	}
+}
+
+// Collector is prometheus.Collector of pagination metrics,
+// it is fed by the interceptor returned by Interceptor
+type Collector struct {
+	countDuration      *prometheus.HistogramVec
+	dataDuration       *prometheus.HistogramVec
+	totalDuration      *prometheus.HistogramVec
+	pageDepth          *prometheus.HistogramVec
+	skipSize           *prometheus.HistogramVec
+	resultSize         *prometheus.HistogramVec
+	validationFailures *prometheus.CounterVec
+}
+
+// NewCollector returns collector of pagination metrics
+func NewCollector(opts ...Option) *Collector {
+	c := config{
+		latencyBuckets: prometheus.DefBuckets,
+		pageBuckets:    prometheus.ExponentialBuckets(1, 2, 12),
+		skipBuckets:    prometheus.ExponentialBuckets(10, 4, 10),
+		resultBuckets:  prometheus.ExponentialBuckets(1, 2, 11),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	histogram := func(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "pagination",
+			Name:        name,
+			Help:        help,
+			Buckets:     buckets,
+			ConstLabels: c.constantLabels,
+		}, labels)
+	}
+	return &Collector{
+		countDuration: histogram("count_duration_seconds",
+			"Latency of counting documents matching paginated query.",
+			c.latencyBuckets, CollectionLabel),
+		dataDuration: histogram("data_duration_seconds",
+			"Latency of fetching page of documents.",
+			c.latencyBuckets, CollectionLabel, StrategyLabel),
+		totalDuration: histogram("duration_seconds",
+			"Latency of serving paginated query.",
+			c.latencyBuckets, CollectionLabel, StrategyLabel),
+		pageDepth: histogram("page_depth",
+			"Requested page number.",
+			c.pageBuckets, CollectionLabel),
+		skipSize: histogram("skip_size",
+			"Number of documents skipped to serve requested page.",
+			c.skipBuckets, CollectionLabel),
+		resultSize: histogram("result_size",
+			"Number of documents returned in page.",
+			c.resultBuckets, CollectionLabel),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "pagination",
+			Name:        "validation_failures_total",
+			Help:        "Number of paginated queries rejected by validation.",
+			ConstLabels: c.constantLabels,
+		}, []string{CollectionLabel, ErrorLabel}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.countDuration, c.dataDuration, c.totalDuration,
+		c.pageDepth, c.skipSize, c.resultSize, c.validationFailures,
+	}
+}
+
+// Interceptor returns interceptor observing paginated queries
+func (c *Collector) Interceptor() paginate.Interceptor {
+	return func(op *paginate.Operation, next paginate.Handler) error {
+		err := next(op)
+		collection := ""
+		if op.Collection != nil {
+			collection = op.Collection.Name()
+		}
+		switch op.Kind {
+		case paginate.ValidateOperation:
+			if err != nil {
+				c.validationFailures.WithLabelValues(collection, errorType(err)).Inc()
+				return err
+			}
+			c.pageDepth.WithLabelValues(collection).Observe(float64(op.Page))
+			c.skipSize.WithLabelValues(collection).Observe(float64(op.Skip))
+		case paginate.PaginateOperation:
+			if errors.Is(err, paginate.ErrPageOutOfRange) {
+				c.validationFailures.WithLabelValues(collection, PageOutOfRangeErrorType).Inc()
+			}
+			c.totalDuration.WithLabelValues(collection, string(op.Strategy)).Observe(op.Duration.Seconds())
+			if op.Result != nil {
+				c.resultSize.WithLabelValues(collection).Observe(float64(op.Result.Pagination.Count))
+			}
+		case paginate.CountOperation:
+			c.countDuration.WithLabelValues(collection).Observe(op.Duration.Seconds())
+		case paginate.FindOperation, paginate.AggregateOperation:
+			c.dataDuration.WithLabelValues(collection, string(op.Kind)).Observe(op.Duration.Seconds())
+		}
+		return err
+	}
+}
+
+// errorType returns error label of validation error
+func errorType(err error) string {
+	if errType, ok := validationErrorTypes[err.Error()]; ok {
+		return errType
+	}
+	return OtherErrorType
+}
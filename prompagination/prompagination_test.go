@@ -0,0 +1,74 @@
+package prompagination
+
+import (
+	paginate "github.com/gobeam/mongo-go-pagination"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector(WithNamespace("app"))
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	// fake driver answering count and serving empty page
+	fake := func(op *paginate.Operation, next paginate.Handler) error {
+		switch op.Kind {
+		case paginate.ValidateOperation, paginate.PaginateOperation:
+			return next(op)
+		case paginate.CountOperation:
+			op.Count = 25
+			op.Duration = 20 * time.Millisecond
+			return nil
+		default:
+			return paginate.ErrPageOutOfRange
+		}
+	}
+
+	var todos []bson.M
+	_, _ = paginate.New(nil).Limit(10).Page(5).Filter(bson.M{}).Decode(&todos).
+		SetOutOfRangePolicy(paginate.OutOfRangeError).Intercept(collector.Interceptor(), fake).Find()
+	_, _ = paginate.New(nil).Limit(10).Page(1).Intercept(collector.Interceptor()).Find()
+	_, _ = paginate.New(nil).Limit(10).Page(1).Filter(bson.M{}).Intercept(collector.Interceptor()).Aggregate()
+
+	expected := `
+# HELP app_pagination_validation_failures_total Number of paginated queries rejected by validation.
+# TYPE app_pagination_validation_failures_total counter
+app_pagination_validation_failures_total{collection="",error="decode_empty"} 1
+app_pagination_validation_failures_total{collection="",error="filter_in_aggregate"} 1
+app_pagination_validation_failures_total{collection="",error="page_out_of_range"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "app_pagination_validation_failures_total"); err != nil {
+		t.Error(err)
+	}
+	expected = `
+# HELP app_pagination_skip_size Number of documents skipped to serve requested page.
+# TYPE app_pagination_skip_size histogram
+app_pagination_skip_size_bucket{collection="",le="10"} 0
+app_pagination_skip_size_bucket{collection="",le="40"} 1
+app_pagination_skip_size_bucket{collection="",le="160"} 1
+app_pagination_skip_size_bucket{collection="",le="640"} 1
+app_pagination_skip_size_bucket{collection="",le="2560"} 1
+app_pagination_skip_size_bucket{collection="",le="10240"} 1
+app_pagination_skip_size_bucket{collection="",le="40960"} 1
+app_pagination_skip_size_bucket{collection="",le="163840"} 1
+app_pagination_skip_size_bucket{collection="",le="655360"} 1
+app_pagination_skip_size_bucket{collection="",le="2.62144e+06"} 1
+app_pagination_skip_size_bucket{collection="",le="+Inf"} 1
+app_pagination_skip_size_sum{collection=""} 40
+app_pagination_skip_size_count{collection=""} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "app_pagination_skip_size"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "app_pagination_count_duration_seconds"); n != 1 {
+		t.Errorf("expected count latency to be observed, got %d series", n)
+	}
+	if n := testutil.CollectAndCount(collector, "app_pagination_duration_seconds"); n != 1 {
+		t.Errorf("expected total latency to be observed, got %d series", n)
+	}
+}
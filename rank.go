@@ -0,0 +1,203 @@
+package mongopagination
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ranking orders documents of query by a value computed by stages opening
+// the pipeline, such as text score of Search or distance of Near
+type ranking struct {
+	// stages compute field of documents matching filter, seek values
+	// of cursor are passed to narrow down documents of Find mode
+	stages func(filter interface{}, seek []interface{}) []bson.M
+	// countFilter returns filter counting documents of Find mode
+	countFilter func(filter interface{}) interface{}
+	// validate checks parameters of ranking when set
+	validate  func() error
+	field     string
+	direction int
+}
+
+// After is function to continue ranked query of Search or Near after the last
+// document of previous page, pass PaginationData.Cursor of that page. The
+// following documents are seeked on rank and _id instead of skipped, page
+// and offset of query are ignored. Cursor is not signed, tokens holding
+// other than one scalar value per sort field fail with CursorTokenError
+func (paging *pagingQuery) After(cursor string) PagingQuery {
+	paging.AfterCursor = cursor
+	return paging
+}
+
+// afterCursor checks ranking of query and returns copy of query positioned
+// at After cursor, the query itself is returned when there is no cursor
+func (paging *pagingQuery) afterCursor() (*pagingQuery, error) {
+	if paging.Ranking != nil && paging.Ranking.validate != nil {
+		if err := paging.Ranking.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if paging.Ranking == nil || paging.AfterCursor == "" {
+		return paging, nil
+	}
+	position, err := decodeScanPosition(paging.AfterCursor, len(paging.sortFields()))
+	if err != nil {
+		return nil, err
+	}
+	after := *paging
+	after.ByOffset = true
+	after.OffsetCount = position.consumed
+	after.SeekValues = position.seek
+	return &after, nil
+}
+
+// unranked returns error for ranked query, its documents are narrowed down
+// by stages of ranking which only Find and Aggregate apply
+func (paging *pagingQuery) unranked() error {
+	if paging.Ranking != nil {
+		return errors.New(RankedQueryError)
+	}
+	return nil
+}
+
+// sortFields returns sort of query, ranked queries are sorted by rank
+// followed by sort fields of query and _id as tie-breaker
+func (paging *pagingQuery) sortFields() bson.D {
+	if paging.Ranking == nil {
+		return paging.SortFields
+	}
+	sort := bson.D{{Key: paging.Ranking.field, Value: paging.Ranking.direction}}
+	for _, field := range paging.SortFields {
+		// sort on rank such as $meta textScore is replaced
+		if field.Key != paging.Ranking.field {
+			sort = append(sort, field)
+		}
+	}
+	return keysetSort(sort)
+}
+
+// rankedPipeline returns pipeline opened by stages of ranking and followed
+// by match of documents after seek values when they are set
+func (paging *pagingQuery) rankedPipeline(filter interface{}, pipeline []bson.M, seek []interface{}) []bson.M {
+	ranked := append(paging.Ranking.stages(filter, seek), pipeline...)
+	if seek != nil {
+		ranked = append(ranked, bson.M{"$match": seekFilter(paging.sortFields(), seek)})
+	}
+	return ranked
+}
+
+// pageStages returns stages sorting documents and fetching page at skip
+func (paging *pagingQuery) pageStages(sort bson.D, skip int64) []bson.M {
+	var stages []bson.M
+	if len(sort) > 0 {
+		stages = append(stages, bson.M{"$sort": sort})
+	}
+	return append(stages, bson.M{"$skip": skip}, bson.M{"$limit": paging.LimitCount})
+}
+
+// rankCursor returns After cursor of document following page data
+// starting at offset, seek values are left out when last document
+// cannot be seeked past
+func (paging *pagingQuery) rankCursor(data []bson.Raw, offset int64) string {
+	if len(data) == 0 {
+		return ""
+	}
+	position := scanPosition{consumed: offset + int64(len(data))}
+	if values, ok := seekValues(paging.sortFields(), data[len(data)-1]); ok {
+		position.seek = values
+	}
+	return encodeScanPosition(position)
+}
+
+// findRanked queries page of ranked query in Find mode with aggregation
+// pipeline so that page can be seeked on rank
+func (paging *pagingQuery) findRanked(ctx context.Context) (*PaginatedData, error) {
+	counted := *paging
+	counted.FilterQuery = paging.Ranking.countFilter(paging.FilterQuery)
+	count, err := counted.count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paginationInfo, err := clampPage(paging.paginator(count), paging.OutOfRange)
+	if err != nil {
+		return nil, err
+	}
+	seek, skip := paging.SeekValues, int64(0)
+	if seek == nil || paginationInfo.Offset != paging.getSkip() {
+		// documents are skipped without cursor and for clamped page
+		seek, skip = nil, paginationInfo.Offset
+	}
+	pipeline := paging.rankedPipeline(paging.FilterQuery, nil, seek)
+	pipeline = append(pipeline, paging.pageStages(paging.sortFields(), skip)...)
+	if paging.Project != nil {
+		pipeline = append(pipeline, bson.M{"$project": includeField(paging.Project, paging.Ranking.field)})
+	}
+
+	collection, err := paging.collection()
+	if err != nil {
+		return nil, err
+	}
+	op := Operation{
+		Kind:             AggregateOperation,
+		Ctx:              ctx,
+		Collection:       collection,
+		Pipeline:         pipeline,
+		AggregateOptions: options.Aggregate(),
+	}
+	if err := paging.execute(&op); err != nil {
+		return nil, err
+	}
+	cursor := op.Cursor
+	defer cursor.Close(ctx)
+	var data []bson.Raw
+	for cursor.Next(ctx) {
+		data = append(data, append(bson.Raw{}, cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if err := decodeRaw(data, paging.Decoder); err != nil {
+		return nil, err
+	}
+	paginationInfo.Count = int64(len(data))
+	result := PaginatedData{
+		Pagination: *paginationInfo.PaginationData(),
+	}
+	if result.Pagination.HasNext {
+		result.Pagination.Cursor = paging.rankCursor(data, paginationInfo.Offset)
+	}
+	return &result, nil
+}
+
+// includeField adds field to inclusion projection so that it is kept
+func includeField(projection interface{}, field string) interface{} {
+	switch v := projection.(type) {
+	case bson.M:
+		for key, value := range v {
+			if key != "_id" && included(value) {
+				included := bson.M{field: 1}
+				for key, value := range v {
+					included[key] = value
+				}
+				return included
+			}
+		}
+	case bson.D:
+		for _, e := range v {
+			if e.Key != "_id" && included(e.Value) {
+				return append(v[:len(v):len(v)], bson.E{Key: field, Value: 1})
+			}
+		}
+	}
+	return projection
+}
+
+// included tells whether projection value includes field
+func included(value interface{}) bool {
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return direction(value) > 0
+}
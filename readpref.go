@@ -0,0 +1,39 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// SetReadPreference is function to set read preference of count and data
+// operations, e.g. readpref.SecondaryPreferred(readpref.WithTagSets(tags),
+// readpref.WithMaxStaleness(90*time.Second)) routes query to secondaries
+func (paging *pagingQuery) SetReadPreference(readPreference *readpref.ReadPref) PagingQuery {
+	paging.ReadPreference = readPreference
+	return paging
+}
+
+// SetReadConcern is function to set read concern of count and data operations
+func (paging *pagingQuery) SetReadConcern(readConcern *readconcern.ReadConcern) PagingQuery {
+	paging.ReadConcern = readConcern
+	return paging
+}
+
+// collection returns collection handle operations are run on. Read preference
+// and read concern of query are applied on a clone so that collection passed
+// by caller is left untouched
+func (paging *pagingQuery) collection() (*mongo.Collection, error) {
+	if paging.Collection == nil || (paging.ReadPreference == nil && paging.ReadConcern == nil) {
+		return paging.Collection, nil
+	}
+	opts := options.Collection()
+	if paging.ReadPreference != nil {
+		opts.SetReadPreference(paging.ReadPreference)
+	}
+	if paging.ReadConcern != nil {
+		opts.SetReadConcern(paging.ReadConcern)
+	}
+	return paging.Collection.Clone(opts)
+}
@@ -0,0 +1,61 @@
+package mongopagination
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/tag"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestPagingQuery_SetReadPreference(t *testing.T) {
+	client, err := mongo.NewClient(options.Client().ApplyURI(DatabaseHost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := client.Database(DatabaseName).Collection(DatabaseCollection)
+	readPreference, err := readpref.New(readpref.SecondaryMode,
+		readpref.WithTagSets(tag.Set{{Name: "region", Value: "eu"}}),
+		readpref.WithMaxStaleness(90*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readConcern := readconcern.Majority()
+
+	vetoErr := errors.New("vetoed")
+	var seen []*mongo.Collection
+	var todos []TodoTest
+	_, err = New(collection).Limit(10).Page(1).Filter(bson.M{}).Decode(&todos).
+		SetReadPreference(readPreference).SetReadConcern(readConcern).
+		Intercept(func(op *Operation, next Handler) error {
+			if op.Kind != CountOperation {
+				return next(op)
+			}
+			seen = append(seen, op.Collection)
+			return vetoErr
+		}).Find()
+	if !errors.Is(err, vetoErr) || len(seen) != 1 {
+		t.Fatalf("expected vetoed count operation, got %v", err)
+	}
+	if seen[0] == collection || seen[0].Name() != collection.Name() {
+		t.Fatalf("expected operation to run on clone of collection")
+	}
+	if collectionField(seen[0], "readPreference") != uintptr(unsafe.Pointer(readPreference)) ||
+		collectionField(seen[0], "readConcern") != uintptr(unsafe.Pointer(readConcern)) {
+		t.Errorf("expected read preference and read concern applied on clone")
+	}
+	if collectionField(collection, "readPreference") == uintptr(unsafe.Pointer(readPreference)) {
+		t.Errorf("expected caller collection to be left untouched")
+	}
+}
+
+// collectionField returns pointer held by unexported field of collection
+func collectionField(collection *mongo.Collection, name string) uintptr {
+	return reflect.ValueOf(collection).Elem().FieldByName(name).Pointer()
+}
@@ -0,0 +1,42 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SearchScore is field holding text score of documents found by Search
+const SearchScore = "score"
+
+// Search is function to search text index of collection for term, language
+// of the text index is used when language is empty. Documents are sorted
+// by text score held in SearchScore field followed by sort fields of query
+// and _id as tie-breaker, pages can be continued with After. In Find mode
+// documents are fetched with aggregation pipeline, collation of query is
+// not applied as text search does not support it. Search results can only
+// be paginated with Find or Aggregate, other ways of reading query return
+// RankedQueryError
+func (paging *pagingQuery) Search(term, language string) PagingQuery {
+	text := bson.M{"$search": term}
+	if language != "" {
+		text["$language"] = language
+	}
+	textFilter := func(filter interface{}) interface{} {
+		if filter == nil {
+			return bson.M{"$text": text}
+		}
+		return bson.M{"$and": []interface{}{filter, bson.M{"$text": text}}}
+	}
+	paging.Ranking = &ranking{
+		stages: func(filter interface{}, _ []interface{}) []bson.M {
+			// $text match has to be the first stage of pipeline
+			return []bson.M{
+				{"$match": textFilter(filter)},
+				{"$addFields": bson.M{SearchScore: bson.M{"$meta": "textScore"}}},
+			}
+		},
+		countFilter: textFilter,
+		field:       SearchScore,
+		direction:   -1,
+	}
+	return paging
+}
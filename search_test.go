@@ -0,0 +1,191 @@
+package mongopagination
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_Search(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("SearchTest")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "title", Value: "text"}}}); err != nil {
+		t.Fatalf("Index create error. Error: %s", err.Error())
+	}
+	docs := []interface{}{
+		bson.M{"_id": "p-0", "title": "coffee coffee beans", "status": "active"},
+		bson.M{"_id": "p-6", "title": "green tea", "status": "active"},
+		bson.M{"_id": "p-7", "title": "coffee cup", "status": "deleted"},
+	}
+	// equal scores and titles are ordered by _id
+	for i := 1; i <= 5; i++ {
+		docs = append(docs, bson.M{"_id": fmt.Sprintf("p-%d", i), "title": "coffee cup", "status": "active"})
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		t.Fatalf("Data insert error. Error: %s", err.Error())
+	}
+	type product struct {
+		ID    string  `bson:"_id"`
+		Title string  `bson:"title"`
+		Score float64 `bson:"score"`
+	}
+	ids := func(products []product) []string {
+		var ids []string
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	// collation is left out of text search which does not support it
+	query := func(limit int64, products *[]product) PagingQuery {
+		return New(collection).Context(ctx).Limit(limit).Page(1).Filter(bson.M{"status": "active"}).Select(bson.M{"title": 1}).
+			SetCollation(&options.Collation{Locale: "en"}).Sort("title", 1).Decode(products).Search("coffee", "en")
+	}
+
+	var all []product
+	paginatedData, err := query(10, &all).Find()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 6 || len(all) != 6 {
+		t.Fatalf("expected 6 active coffee products, got %v of %d", ids(all), paginatedData.Pagination.Total)
+	}
+	for i, p := range all {
+		if p.Score <= 0 || p.Title == "" {
+			t.Errorf("expected projected title and score, got %+v", p)
+		}
+		if i > 0 && (p.Score > all[i-1].Score || p.Score == all[i-1].Score && p.ID < all[i-1].ID) {
+			t.Errorf("expected products sorted by score and _id, got %+v", all)
+		}
+	}
+
+	// pages continued with cursor hold the same products in the same order
+	var find, aggregate []product
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		var products []product
+		paginatedData, err = query(2, &products).After(cursor).Find()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		find = append(find, products...)
+		cursor = paginatedData.Pagination.Cursor
+	}
+	if !reflect.DeepEqual(ids(find), ids(all)) || cursor != "" {
+		t.Errorf("expected pages of find to hold %v, got %v", ids(all), ids(find))
+	}
+	// total and facets count every found product on every page
+	titles := []FacetBucket{{Value: "coffee cup", Count: 5}, {Value: "coffee coffee beans", Count: 1}}
+	cursor = ""
+	for page := 0; page < 3; page++ {
+		paginatedData, err = New(collection).Context(ctx).Limit(2).Page(1).Sort("title", 1).Search("coffee", "en").After(cursor).
+			AddFacets(Terms("title", "title", 0)).Aggregate(bson.M{"$match": bson.M{"status": "active"}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if paginatedData.Pagination.Total != 6 {
+			t.Errorf("expected total of 6 on every page, got %+v", paginatedData.Pagination)
+		}
+		if !reflect.DeepEqual(paginatedData.Facets["title"], titles) {
+			t.Errorf("expected facet %v on page %d, got %v", titles, page+1, paginatedData.Facets["title"])
+		}
+		for _, raw := range paginatedData.Data {
+			var p product
+			if err := bson.Unmarshal(raw, &p); err != nil {
+				t.Fatal(err)
+			}
+			aggregate = append(aggregate, p)
+		}
+		cursor = paginatedData.Pagination.Cursor
+	}
+	if !reflect.DeepEqual(ids(aggregate), ids(all)) || cursor != "" {
+		t.Errorf("expected pages of aggregate to hold %v, got %v", ids(all), ids(aggregate))
+	}
+}
+
+func TestPagingQuery_SearchCursorToken(t *testing.T) {
+	match := bson.M{"$match": bson.M{"status": "active"}}
+	_, err := New(nil).Limit(10).Page(1).Search("coffee", "").After("!").Aggregate(match)
+	if err == nil || err.Error() != CursorTokenError {
+		t.Errorf("expected cursor token error, got %v", err)
+	}
+	// seek values are read by equality match of seek filter
+	tampered := encodeScanPosition(scanPosition{consumed: 20, seek: []interface{}{bson.M{"$ne": nil}, bson.M{"$ne": nil}}})
+	_, err = New(nil).Limit(10).Page(1).Search("coffee", "").After(tampered).Aggregate(match)
+	if err == nil || err.Error() != CursorTokenError {
+		t.Errorf("expected cursor token error of operator seek values, got %v", err)
+	}
+}
+
+func TestRankCursor(t *testing.T) {
+	paging := New(nil).Limit(2).Search("coffee", "").(*pagingQuery)
+	first, _ := bson.Marshal(bson.M{"_id": "a", SearchScore: 2.5})
+	last, _ := bson.Marshal(bson.M{"_id": "b", SearchScore: 1.5})
+	position, err := decodeScanPosition(paging.rankCursor([]bson.Raw{first, last}, 4), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(position, scanPosition{consumed: 6, seek: []interface{}{1.5, "b"}}) {
+		t.Errorf("expected cursor after last document, got %+v", position)
+	}
+	if paging.rankCursor(nil, 4) != "" {
+		t.Errorf("expected no cursor of empty page")
+	}
+}
+
+func TestIncludeField(t *testing.T) {
+	cases := []struct {
+		projection interface{}
+		expected   interface{}
+	}{
+		{bson.M{"title": 1}, bson.M{"title": 1, "score": 1}},
+		{bson.M{"_id": 0, "title": true}, bson.M{"_id": 0, "title": true, "score": 1}},
+		{bson.M{"body": 0}, bson.M{"body": 0}},
+		{bson.D{{Key: "title", Value: 1}}, bson.D{{Key: "title", Value: 1}, {Key: "score", Value: 1}}},
+	}
+	for _, c := range cases {
+		if projection := includeField(c.projection, "score"); !reflect.DeepEqual(projection, c.expected) {
+			t.Errorf("expected %v, got %v", c.expected, projection)
+		}
+	}
+}
+
+func TestRankedQueryError(t *testing.T) {
+	query := func() PagingQuery {
+		var todos []TodoTest
+		return New(nil).Limit(10).Page(1).Filter(bson.M{}).Decode(&todos).Search("coffee", "")
+	}
+	check := func(name string, err error) {
+		if err == nil || err.Error() != RankedQueryError {
+			t.Errorf("expected ranked query error of %s, got %v", name, err)
+		}
+	}
+	for _, err := range All[TodoTest](query()) {
+		check("All", err)
+	}
+	_, err := Export(query(), io.Discard, ExportOptions{Format: NDJSON})
+	check("Export", err)
+	for item := range Partitioned[TodoTest](query(), PartitionOptions{}) {
+		check("Partitioned", item.Err)
+	}
+	_, err = Merge(query(), []*mongo.Collection{nil}, "")
+	check("Merge", err)
+	_, err = query().FindArray("tags", nil)
+	check("FindArray", err)
+	_, err = New(nil).Limit(10).Page(1).Search("coffee", "").Distinct("category", "")
+	check("Distinct", err)
+	_, err = New(nil).Limit(10).Page(1).Near(GeoNear{Key: "location"}).AggregateGroups(GroupBy{Key: "category"})
+	check("AggregateGroups", err)
+	_, err = New(nil).Limit(10).Page(1).Search("coffee", "").Union("archived").Aggregate(bson.M{"$match": bson.M{}})
+	check("Union", err)
+}
@@ -0,0 +1,196 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces values of redacted fields in logged queries
+const Redacted = "[REDACTED]"
+
+// slowLogPhasesKey is context key of phases recorded during paginated call
+type slowLogPhasesKey struct{}
+
+// slowLogPhase is count or data phase of paginated call
+type slowLogPhase struct {
+	kind     OperationKind
+	filter   interface{}
+	pipeline interface{}
+	duration time.Duration
+}
+
+type slowLogPhases struct {
+	sync.Mutex
+	phases []slowLogPhase
+}
+
+// SlowLog logs paginated calls taking longer than threshold with logger at
+// warn level. Values of fields named in redactFields are replaced with
+// Redacted in the logged filter and pipeline, nested fields are matched by
+// full dotted path or by their last path segment. Duration, filter and
+// pipeline of every phase are grouped under count or data, repeated phases
+// are numbered such as data_2
+func (paging *pagingQuery) SlowLog(logger *slog.Logger, threshold time.Duration, redactFields ...string) PagingQuery {
+	return paging.Intercept(slowLogInterceptor(logger, threshold, redactFields))
+}
+
+func slowLogInterceptor(logger *slog.Logger, threshold time.Duration, redactFields []string) Interceptor {
+	redact := make(map[string]bool, len(redactFields))
+	for _, field := range redactFields {
+		redact[field] = true
+	}
+	return func(op *Operation, next Handler) error {
+		switch op.Kind {
+		case ValidateOperation:
+			return next(op)
+		case PaginateOperation:
+		default:
+			// record phase in call it belongs to
+			err := next(op)
+			if recorded, ok := op.Ctx.Value(slowLogPhasesKey{}).(*slowLogPhases); ok {
+				recorded.Lock()
+				recorded.phases = append(recorded.phases, slowLogPhase{
+					kind:     op.Kind,
+					filter:   op.Filter,
+					pipeline: op.Pipeline,
+					duration: op.Duration,
+				})
+				recorded.Unlock()
+			}
+			return err
+		}
+
+		recorded := &slowLogPhases{}
+		op.Ctx = context.WithValue(op.Ctx, slowLogPhasesKey{}, recorded)
+		err := next(op)
+		if op.Duration <= threshold {
+			return err
+		}
+
+		attrs := []slog.Attr{
+			slog.String("strategy", string(op.Strategy)),
+			slog.Duration("duration", op.Duration),
+			slog.Int64("page", op.Page),
+			slog.Int64("limit", op.Limit),
+			slog.Int64("skip", op.Skip),
+			slog.Any("sort", op.Sort),
+		}
+		if op.Collection != nil {
+			attrs = append(attrs, slog.String("collection", op.Collection.Name()))
+		}
+		recorded.Lock()
+		attrs = append(attrs, phaseAttrs(recorded.phases, redact)...)
+		recorded.Unlock()
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(op.Ctx, slog.LevelWarn, "slow pagination query", attrs...)
+		return err
+	}
+}
+
+// phaseAttrs returns group of attributes of every phase named after the
+// phase, repeated phases such as data of clamped page are numbered from 2,
+// followed by name of the slowest phase
+func phaseAttrs(phases []slowLogPhase, redact map[string]bool) []slog.Attr {
+	var attrs []slog.Attr
+	seen := map[string]int{}
+	var slowest string
+	var slowestDuration time.Duration
+	for _, phase := range phases {
+		name := phaseName(phase.kind)
+		seen[name]++
+		if seen[name] > 1 {
+			name += "_" + strconv.Itoa(seen[name])
+		}
+		group := []interface{}{slog.Duration("duration", phase.duration)}
+		if phase.filter != nil {
+			group = append(group, slog.Any("filter", redactQuery(phase.filter, "", redact)))
+		}
+		if phase.pipeline != nil {
+			group = append(group, slog.Any("pipeline", redactQuery(phase.pipeline, "", redact)))
+		}
+		attrs = append(attrs, slog.Group(name, group...))
+		if slowest == "" || phase.duration > slowestDuration {
+			slowest, slowestDuration = name, phase.duration
+		}
+	}
+	if slowest != "" {
+		attrs = append(attrs, slog.String("slow_phase", slowest))
+	}
+	return attrs
+}
+
+// phaseName returns name of phase operation belongs to
+func phaseName(kind OperationKind) string {
+	if kind == CountOperation {
+		return "count"
+	}
+	return "data"
+}
+
+// redactQuery returns copy of query with values of redacted fields replaced.
+// Operator keys such as $match are not part of field path
+func redactQuery(query interface{}, path string, redact map[string]bool) interface{} {
+	if len(redact) == 0 {
+		return query
+	}
+	field := func(key string) (string, bool) {
+		if strings.HasPrefix(key, "$") {
+			return path, false
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		segments := strings.Split(fieldPath, ".")
+		return fieldPath, redact[fieldPath] || redact[segments[len(segments)-1]]
+	}
+	switch v := query.(type) {
+	case bson.M:
+		redacted := make(bson.M, len(v))
+		for key, value := range v {
+			fieldPath, ok := field(key)
+			if ok {
+				redacted[key] = Redacted
+			} else {
+				redacted[key] = redactQuery(value, fieldPath, redact)
+			}
+		}
+		return redacted
+	case map[string]interface{}:
+		return redactQuery(bson.M(v), path, redact)
+	case bson.D:
+		redacted := make(bson.D, 0, len(v))
+		for _, e := range v {
+			fieldPath, ok := field(e.Key)
+			if ok {
+				redacted = append(redacted, bson.E{Key: e.Key, Value: Redacted})
+			} else {
+				redacted = append(redacted, bson.E{Key: e.Key, Value: redactQuery(e.Value, fieldPath, redact)})
+			}
+		}
+		return redacted
+	case []bson.M:
+		redacted := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			redacted = append(redacted, redactQuery(item, path, redact))
+		}
+		return redacted
+	case bson.A:
+		return redactQuery([]interface{}(v), path, redact)
+	case []interface{}:
+		redacted := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			redacted = append(redacted, redactQuery(item, path, redact))
+		}
+		return redacted
+	default:
+		return query
+	}
+}
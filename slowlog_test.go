@@ -0,0 +1,151 @@
+package mongopagination
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_SlowLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	// fake driver with slow count phase
+	fake := func(op *Operation, next Handler) error {
+		switch op.Kind {
+		case ValidateOperation, PaginateOperation:
+			return next(op)
+		case CountOperation:
+			time.Sleep(20 * time.Millisecond)
+			op.Duration = 20 * time.Millisecond
+			op.Count = 0
+			return nil
+		default:
+			return errors.New("no data")
+		}
+	}
+
+	var todos []TodoTest
+	filter := bson.M{"status": "active", "owner": bson.M{"email": "a@b.c"}}
+	_, _ = New(nil).Limit(10).Page(2).Sort("title", 1).Filter(filter).Decode(&todos).
+		SlowLog(logger, 10*time.Millisecond, "email").Intercept(fake).Find()
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected one slow query record, got %q", buf.String())
+	}
+	if record["msg"] != "slow pagination query" || record["level"] != "WARN" {
+		t.Errorf("unexpected record %v", record)
+	}
+	if record["slow_phase"] != "count" || record["skip"] != float64(10) || record["strategy"] != "find" {
+		t.Errorf("expected slow count phase with skip 10, got %v", record)
+	}
+	// unmarshalling keeps the last one of duplicate keys
+	if duplicates := duplicateKeys(buf.Bytes()); len(duplicates) > 0 {
+		t.Errorf("expected unique keys, got duplicates %v in %s", duplicates, buf.String())
+	}
+	expected := map[string]interface{}{"status": "active", "owner": map[string]interface{}{"email": Redacted}}
+	for _, phase := range []string{"count", "data"} {
+		group, _ := record[phase].(map[string]interface{})
+		if !reflect.DeepEqual(group["filter"], expected) || group["duration"] == nil {
+			t.Errorf("expected %s phase with redacted filter %v, got %v", phase, expected, record[phase])
+		}
+	}
+
+	// fast calls are not logged
+	buf.Reset()
+	_, _ = New(nil).Limit(10).Page(1).Filter(bson.M{}).Decode(&todos).
+		SlowLog(logger, time.Minute).Intercept(fake).Find()
+	if buf.Len() != 0 {
+		t.Errorf("expected no record for fast query, got %q", buf.String())
+	}
+}
+
+func TestPhaseAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	// data phase is repeated when clamped page is queried again
+	phases := []slowLogPhase{
+		{kind: AggregateOperation, pipeline: []bson.M{{"$skip": 50}}, duration: time.Millisecond},
+		{kind: AggregateOperation, pipeline: []bson.M{{"$skip": 40}}, duration: 3 * time.Millisecond},
+		{kind: CountOperation, filter: bson.M{}, duration: 2 * time.Millisecond},
+	}
+	logger.LogAttrs(context.Background(), slog.LevelWarn, "slow", phaseAttrs(phases, nil)...)
+	if duplicates := duplicateKeys(buf.Bytes()); len(duplicates) > 0 {
+		t.Errorf("expected unique keys, got duplicates %v in %s", duplicates, buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["data"] == nil || record["data_2"] == nil || record["count"] == nil || record["slow_phase"] != "data_2" {
+		t.Errorf("expected numbered data phases with slow data_2, got %v", record)
+	}
+}
+
+// duplicateKeys returns keys repeated in the same object of JSON stream
+func duplicateKeys(data []byte) []string {
+	type object struct {
+		keys      map[string]bool
+		expectKey bool
+	}
+	var stack []*object
+	var duplicates []string
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return duplicates
+		}
+		if token == json.Delim('}') || token == json.Delim(']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		var top *object
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.keys != nil {
+			if top.expectKey {
+				key := token.(string)
+				if top.keys[key] {
+					duplicates = append(duplicates, key)
+				}
+				top.keys[key] = true
+				top.expectKey = false
+				continue
+			}
+			top.expectKey = true
+		}
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &object{keys: map[string]bool{}, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &object{})
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"$or": bson.A{bson.M{"user.email": "a@b.c"}, bson.M{"name": "x"}}}},
+		{"$match": bson.D{{Key: "user", Value: bson.M{"ssn": "123"}}}},
+	}
+	redacted := redactQuery(pipeline, "", map[string]bool{"email": true, "user.ssn": true})
+	expected := []interface{}{
+		bson.M{"$match": bson.M{"$or": []interface{}{bson.M{"user.email": Redacted}, bson.M{"name": "x"}}}},
+		bson.M{"$match": bson.D{{Key: "user", Value: bson.M{"ssn": Redacted}}}},
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("expected %v, got %v", expected, redacted)
+	}
+	if pipeline[1]["$match"].(bson.D)[0].Value.(bson.M)["ssn"] != "123" {
+		t.Errorf("expected original query to stay untouched")
+	}
+}
@@ -0,0 +1,116 @@
+package mongopagination
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+	"strings"
+)
+
+// SetSession is function to run count and data operations of query in session.
+// SessionContext can be passed as well since it implements mongo.Session
+func (paging *pagingQuery) SetSession(session mongo.Session) PagingQuery {
+	paging.Session = session
+	return paging
+}
+
+// Snapshot enables snapshot reads so that every page of query is served from
+// the same point in time. Pass empty token for the first page, it records
+// cluster time of the snapshot in PaginationData.Snapshot which has to be
+// passed for following pages. Session set with SetSession must have been
+// started with snapshot option, otherwise a snapshot session is started
+func (paging *pagingQuery) Snapshot(token string) PagingQuery {
+	paging.SnapshotReads = true
+	paging.SnapshotToken = token
+	return paging
+}
+
+// EncodeSnapshot returns continuation token of snapshot cluster time
+func EncodeSnapshot(atClusterTime primitive.Timestamp) string {
+	return fmt.Sprintf("%d.%d", atClusterTime.T, atClusterTime.I)
+}
+
+// ParseSnapshot returns snapshot cluster time of continuation token
+func ParseSnapshot(token string) (primitive.Timestamp, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return primitive.Timestamp{}, errors.New(SnapshotTokenError)
+	}
+	t, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return primitive.Timestamp{}, errors.New(SnapshotTokenError)
+	}
+	i, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return primitive.Timestamp{}, errors.New(SnapshotTokenError)
+	}
+	return primitive.Timestamp{T: uint32(t), I: uint32(i)}, nil
+}
+
+// withSession returns context running operations in session of query. For
+// snapshot reads it pins the session to snapshot cluster time of token. The
+// returned function reports snapshot token and ends session it started
+func (paging *pagingQuery) withSession(ctx context.Context) (context.Context, func() string, error) {
+	if paging.Session == nil && !paging.SnapshotReads {
+		return ctx, func() string { return "" }, nil
+	}
+	session := paging.Session
+	started := false
+	if session == nil {
+		var err error
+		session, err = paging.Collection.Database().Client().StartSession(options.Session().SetSnapshot(true))
+		if err != nil {
+			return nil, nil, err
+		}
+		started = true
+	}
+	var clientSession *sessionClient
+	if xs, ok := session.(mongo.XSession); ok && paging.SnapshotReads {
+		clientSession = &sessionClient{xs}
+		if paging.SnapshotToken != "" {
+			atClusterTime, err := ParseSnapshot(paging.SnapshotToken)
+			if err != nil {
+				if started {
+					session.EndSession(ctx)
+				}
+				return nil, nil, err
+			}
+			clientSession.setSnapshotTime(atClusterTime)
+		}
+	}
+	done := func() string {
+		token := ""
+		if clientSession != nil {
+			if atClusterTime := clientSession.snapshotTime(); atClusterTime != nil {
+				token = EncodeSnapshot(*atClusterTime)
+			}
+		}
+		if started {
+			session.EndSession(ctx)
+		}
+		return token
+	}
+	return mongo.NewSessionContext(ctx, session), done, nil
+}
+
+// sessionClient gives access to snapshot cluster time of session
+type sessionClient struct {
+	session mongo.XSession
+}
+
+func (s *sessionClient) snapshotTime() *primitive.Timestamp {
+	if client := s.session.ClientSession(); client != nil {
+		return client.SnapshotTime
+	}
+	return nil
+}
+
+func (s *sessionClient) setSnapshotTime(atClusterTime primitive.Timestamp) {
+	if client := s.session.ClientSession(); client != nil {
+		client.SnapshotTime = &atClusterTime
+	}
+}
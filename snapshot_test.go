@@ -0,0 +1,66 @@
+package mongopagination
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+)
+
+func TestSnapshotToken(t *testing.T) {
+	atClusterTime := primitive.Timestamp{T: 1700000000, I: 7}
+	token := EncodeSnapshot(atClusterTime)
+	parsed, err := ParseSnapshot(token)
+	if err != nil || parsed != atClusterTime {
+		t.Fatalf("expected %v, got %v and %v", atClusterTime, parsed, err)
+	}
+	for _, token := range []string{"", "1", "a.b", "1.2.3", "-1.2", "4294967296.1"} {
+		if _, err := ParseSnapshot(token); err == nil || err.Error() != SnapshotTokenError {
+			t.Errorf("expected snapshot token error for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestPagingQuery_Snapshot(t *testing.T) {
+	// sessions are started without contacting the server
+	client, err := mongo.NewClient(options.Client().ApplyURI(DatabaseHost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	collection := client.Database(DatabaseName).Collection(DatabaseCollection)
+
+	vetoErr := errors.New("vetoed")
+	var sessionSeen mongo.Session
+	var todos []TodoTest
+	_, err = New(collection).Limit(10).Page(2).Filter(bson.M{}).Decode(&todos).Snapshot("1700000000.7").
+		Intercept(func(op *Operation, next Handler) error {
+			if op.Kind != CountOperation {
+				return next(op)
+			}
+			sessionSeen = mongo.SessionFromContext(op.Ctx)
+			return vetoErr
+		}).Find()
+	if !errors.Is(err, vetoErr) {
+		t.Fatalf("expected vetoed error, got %v", err)
+	}
+	xs, ok := sessionSeen.(mongo.XSession)
+	if !ok {
+		t.Fatalf("expected count to run in session")
+	}
+	snapshotTime := xs.ClientSession().SnapshotTime
+	if !xs.ClientSession().Snapshot || snapshotTime == nil || *snapshotTime != (primitive.Timestamp{T: 1700000000, I: 7}) {
+		t.Errorf("expected snapshot session pinned to token cluster time, got %v", snapshotTime)
+	}
+
+	_, err = New(collection).Limit(10).Page(2).Filter(bson.M{}).Decode(&todos).Snapshot("bad").Find()
+	if err == nil || err.Error() != SnapshotTokenError {
+		t.Errorf("expected snapshot token error, got %v", err)
+	}
+}
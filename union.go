@@ -0,0 +1,41 @@
+package mongopagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UnionWith is collection of the same database whose documents are
+// paginated along with documents of aggregate pipeline
+type UnionWith struct {
+	Collection string
+	// Pipeline is run on documents of collection before they are added,
+	// pipeline passed to Aggregate is run when it is empty
+	Pipeline []bson.M
+}
+
+// Union is function to add documents of collection in the same database to
+// result of Aggregate. Documents are added with $unionWith stage before
+// sorting and paging so that total and sort span every collection. Unions
+// cannot be added to query ranked by Search or Near
+func (paging *pagingQuery) Union(collection string, pipeline ...bson.M) PagingQuery {
+	paging.Unions = append(paging.Unions, UnionWith{Collection: collection, Pipeline: pipeline})
+	return paging
+}
+
+// unionStages returns $unionWith stages of unions of query, collections
+// without pipeline are run through pipeline of query
+func (paging *pagingQuery) unionStages(pipeline []bson.M) []bson.M {
+	stages := make([]bson.M, 0, len(paging.Unions))
+	for _, union := range paging.Unions {
+		stage := bson.M{"coll": union.Collection}
+		unionPipeline := union.Pipeline
+		if len(unionPipeline) == 0 {
+			unionPipeline = pipeline
+		}
+		if len(unionPipeline) > 0 {
+			stage["pipeline"] = unionPipeline
+		}
+		stages = append(stages, bson.M{"$unionWith": stage})
+	}
+	return stages
+}
@@ -0,0 +1,93 @@
+package mongopagination
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagingQuery_Union(t *testing.T) {
+	_, session := NewConnection()
+	db := session.Database(DatabaseName)
+	collection := db.Collection("UnionTest")
+	archived := db.Collection("UnionTestArchived")
+	imported := db.Collection("UnionTestImported")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer collection.Drop(ctx)
+	defer archived.Drop(ctx)
+	defer imported.Drop(ctx)
+	seed := map[string][]interface{}{
+		"UnionTest": {
+			bson.M{"title": "a-1", "status": "active"},
+			bson.M{"title": "a-2", "status": "active"},
+			bson.M{"title": "a-3", "status": "active"},
+			bson.M{"title": "a-4", "status": "deleted"},
+		},
+		"UnionTestArchived": {
+			bson.M{"title": "b-1", "status": "active"},
+			bson.M{"title": "b-2", "status": "active"},
+			bson.M{"title": "b-3", "status": "deleted"},
+		},
+		"UnionTestImported": {
+			bson.M{"title": "c-1", "status": "deleted", "imported": true},
+			bson.M{"title": "c-2", "status": "active", "imported": false},
+		},
+	}
+	for name, docs := range seed {
+		if _, err := db.Collection(name).InsertMany(ctx, docs); err != nil {
+			t.Fatalf("Data insert error. Error: %s", err.Error())
+		}
+	}
+
+	// archived documents are run through pipeline of query,
+	// imported ones through their own pipeline only
+	match := bson.M{"$match": bson.M{"status": "active"}}
+	pipeline := make([]bson.M, 1, 4)
+	pipeline[0] = match
+	query := func(page int64) (*PaginatedData, error) {
+		return New(collection).Context(ctx).Limit(4).Page(page).Sort("title", 1).
+			Union("UnionTestArchived").
+			Union("UnionTestImported", bson.M{"$match": bson.M{"imported": true}}).
+			Aggregate(pipeline)
+	}
+	titles := func(data []bson.Raw) []string {
+		var titles []string
+		for _, raw := range data {
+			titles = append(titles, raw.Lookup("title").StringValue())
+		}
+		return titles
+	}
+
+	paginatedData, err := query(1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if paginatedData.Pagination.Total != 6 || paginatedData.Pagination.TotalPage != 2 {
+		t.Errorf("expected total of every collection, got %+v", paginatedData.Pagination)
+	}
+	if got := titles(paginatedData.Data); !reflect.DeepEqual(got, []string{"a-1", "a-2", "a-3", "b-1"}) {
+		t.Errorf("expected first page sorted across collections, got %v", got)
+	}
+
+	paginatedData, err = query(2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := titles(paginatedData.Data); !reflect.DeepEqual(got, []string{"b-2", "c-1"}) {
+		t.Errorf("expected second page sorted across collections, got %v", got)
+	}
+	if len(pipeline) != 1 || pipeline[:2][1] != nil {
+		t.Errorf("expected pipeline of caller to be left untouched, got %v", pipeline[:cap(pipeline)])
+	}
+}
+
+func TestPagingQuery_UnionCollection(t *testing.T) {
+	match := bson.M{"$match": bson.M{"status": "active"}}
+	_, err := New(nil).Limit(10).Page(1).Union("").Aggregate(match)
+	if err == nil || err.Error() != UnionCollectionError {
+		t.Errorf("expected union collection error, got %v", err)
+	}
+}